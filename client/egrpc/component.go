@@ -3,27 +3,49 @@ package egrpc
 import (
 	"context"
 	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap/zapgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
 
+	"github.com/gotomicro/ego/core/ehealth"
 	"github.com/gotomicro/ego/core/elog"
 	"github.com/gotomicro/ego/internal/egrpclog"
+	"github.com/gotomicro/ego/internal/etls"
 )
 
 // PackageName 设置包名
 const PackageName = "client.egrpc"
 
+// connDrainTimeout 切换连接后，等待旧连接上请求结束的最长时间
+const connDrainTimeout = 30 * time.Second
+
 // Component 组件
 type Component struct {
 	name   string
 	config *Config
 	logger *elog.Component
+	// ClientConn 连接池的入口，请求转发到 Component 当前的连接池，延迟建立连接、切换连接和连接池对它同样生效
 	*grpc.ClientConn
-	err error
+	err           error
+	closers       []io.Closer // 证书、token 文件监听等需要随连接一起关闭的资源
+	dialOptions   []grpc.DialOption
+	pool          atomic.Pointer[connPool] // 当前使用的连接池，Addr、BalancerName 变更后会被替换
+	readinessName string                   // 注册到治理服务的 readiness 检查名，关闭时删除
+
+	mu           sync.Mutex // 串行化连接切换
+	addr         string
+	balancerName string
 }
 
 func newComponent(name string, config *Config, logger *elog.Component) *Component {
@@ -31,56 +53,64 @@ func newComponent(name string, config *Config, logger *elog.Component) *Componen
 		// grpc框架日志，因为官方grpc日志是单例，所以这里要处理下
 		grpclog.SetLoggerV2(zapgrpc.NewLogger(egrpclog.Build().ZapLogger()))
 	}
-	var ctx = context.Background()
 	var dialOptions = config.dialOptions
-	// 默认配置使用block
-	if config.EnableBlock {
-		if config.DialTimeout > time.Duration(0) {
-			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeoutCause(ctx, config.DialTimeout, fmt.Errorf("grpc client conn dial timeout"))
-			defer cancel()
-		}
 
-		dialOptions = append(dialOptions, grpc.WithBlock())
+	var closers = append([]io.Closer(nil), config.closers...)
+	if config.EnableTLS {
+		tlsReloader, err := etls.NewReloader(config.TLSCertFile, config.TLSKeyFile, config.TLSCAFiles, true, logger)
+		if err != nil {
+			return failComponent(name, config, logger, "tls err", err)
+		}
+		closers = append(closers, tlsReloader)
+		tlsConfig := tlsReloader.ClientConfig(config.TLSServerName, config.TLSInsecureSkipVerify, config.TLSAllowedSANs)
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else if config.EnableWithInsecure {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
-	if config.EnableWithInsecure {
-		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	perRPCCredentials, err := newPerRPCCredentials(config, logger)
+	if err != nil {
+		return failComponent(name, config, logger, "credentials err", err)
+	}
+	if closer, ok := perRPCCredentials.(io.Closer); ok {
+		closers = append(closers, closer)
+	}
+	if perRPCCredentials != nil {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(perRPCCredentials))
 	}
 
 	if config.keepAlive != nil {
 		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(*config.keepAlive))
 	}
 
-	// service config 默认开启，且 grpc 1.46 及以上版本废弃了 WithBalancer 方法，改用 service config 配置 lb，但是开启后，在
-	// grpc 1.40 以上会导致 dns 多一次解析 txt 内容（目测是为了做 grpc 的 load balance 策略，但我们实际上不会用到）
-	// 因为这个 service config dns域名通常是没有设置dns解析，所以会跳过k8s的dns，穿透到上一级的dns，而如果dns配置有问题或者不存在，那么会查询非常长的时间（通常在20s或者更长）
-	// 在上面场景下，配置为 false 禁用 service config，可以加快我们的启动时间或者提升我们的性能，**但请注意，禁用后，我们配置的 LB 将无法生效，默认为 pick_first 策略**
-	if !config.EnableServiceConfig {
-		dialOptions = append(dialOptions, grpc.WithDisableServiceConfig())
-		if config.BalancerName != "pick_first" {
-			elog.Warn(fmt.Sprintf("The LB policy `%s` will be ignored and use `pick_first` as default since you disabled service config", config.BalancerName))
-		}
-	} else {
-		dialOptions = append(dialOptions, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, config.BalancerName)))
-	}
-
 	dialOptions = append(dialOptions, grpc.FailOnNonTempDialError(config.EnableFailOnNonTempDialError))
 
 	if config.MaxCallRecvMsgSize != DefaultMaxCallRecvMsgSize {
 		dialOptions = append(dialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(config.MaxCallRecvMsgSize)))
 	}
 
-	startTime := time.Now()
-	cc, err := grpc.DialContext(ctx, config.Addr, dialOptions...)
-
 	component := &Component{
-		name:       name,
-		config:     config,
-		logger:     logger,
-		ClientConn: cc,
+		name:         name,
+		config:       config,
+		logger:       logger,
+		closers:      closers,
+		dialOptions:  dialOptions,
+		addr:         config.Addr,
+		balancerName: config.BalancerName,
 	}
+	component.ClientConn = component.newEntryConn()
 
+	// 延迟建立连接，在第一次请求或调用 Ready 时建立连接
+	if config.EnableLazyDial {
+		logger.Info("start grpc client with lazy dial", elog.FieldName(name))
+		return component
+	}
+
+	startTime := time.Now()
+	ccs, err := component.dialPool(config.Addr, config.BalancerName)
+	if err == nil {
+		component.pool.Store(component.newConnPool(ccs))
+	}
 	if err != nil {
 		component.err = err
 		if config.OnFail == "panic" {
@@ -94,7 +124,254 @@ func newComponent(name string, config *Config, logger *elog.Component) *Componen
 	return component
 }
 
+// dialPool 建立 PoolSize 个连接，任意一个连接失败时关闭已经建立的连接
+func (c *Component) dialPool(addr, balancerName string) ([]*grpc.ClientConn, error) {
+	size := c.config.PoolSize
+	if size < 1 {
+		size = 1
+	}
+	ccs := make([]*grpc.ClientConn, 0, size)
+	for i := 0; i < size; i++ {
+		cc, err := c.dial(addr, balancerName)
+		if err != nil {
+			for _, cc := range ccs {
+				_ = cc.Close()
+			}
+			return nil, err
+		}
+		ccs = append(ccs, cc)
+	}
+	return ccs, nil
+}
+
+// dial 使用指定的地址和负载均衡方式建立连接
+func (c *Component) dial(addr, balancerName string) (*grpc.ClientConn, error) {
+	var ctx = context.Background()
+	var dialOptions = append([]grpc.DialOption{}, c.dialOptions...)
+	// 默认配置使用block，延迟建立连接时不阻塞请求
+	if c.config.EnableBlock && !c.config.EnableLazyDial {
+		if c.config.DialTimeout > time.Duration(0) {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeoutCause(ctx, c.config.DialTimeout, fmt.Errorf("grpc client conn dial timeout"))
+			defer cancel()
+		}
+
+		dialOptions = append(dialOptions, grpc.WithBlock())
+	}
+
+	// service config 默认开启，且 grpc 1.46 及以上版本废弃了 WithBalancer 方法，改用 service config 配置 lb，但是开启后，在
+	// grpc 1.40 以上会导致 dns 多一次解析 txt 内容（目测是为了做 grpc 的 load balance 策略，但我们实际上不会用到）
+	// 因为这个 service config dns域名通常是没有设置dns解析，所以会跳过k8s的dns，穿透到上一级的dns，而如果dns配置有问题或者不存在，那么会查询非常长的时间（通常在20s或者更长）
+	// 在上面场景下，配置为 false 禁用 service config，可以加快我们的启动时间或者提升我们的性能，**但请注意，禁用后，我们配置的 LB 将无法生效，默认为 pick_first 策略**
+	if !c.config.EnableServiceConfig {
+		dialOptions = append(dialOptions, grpc.WithDisableServiceConfig())
+		if balancerName != "pick_first" {
+			elog.Warn(fmt.Sprintf("The LB policy `%s` will be ignored and use `pick_first` as default since you disabled service config", balancerName))
+		}
+	} else {
+		dialOptions = append(dialOptions, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, balancerName)))
+	}
+	return grpc.DialContext(ctx, addr, dialOptions...)
+}
+
+// failComponent 构建连接前出错，根据 OnFail 决定 panic 还是记录错误
+func failComponent(name string, config *Config, logger *elog.Component, errKind string, err error) *Component {
+	component := &Component{name: name, config: config, logger: logger, err: err}
+	component.ClientConn = component.newEntryConn()
+	if config.OnFail == "panic" {
+		logger.Panic("dial grpc server", elog.FieldErrKind(errKind), elog.FieldErr(err), elog.FieldKey(name), elog.FieldAddr(config.Addr))
+		return component
+	}
+	logger.Error("dial grpc server", elog.FieldErrKind(errKind), elog.FieldErr(err), elog.FieldKey(name), elog.FieldAddr(config.Addr))
+	return component
+}
+
+// newEntryConn 创建作为连接池入口的 ClientConn，使用不解析地址的 resolver，不会建立网络连接
+// 通过拦截器把请求转发到 Component，兼容 helloworld.NewGreeterClient(cmp.ClientConn) 的用法
+func (c *Component) newEntryConn() *grpc.ClientConn {
+	cc, err := grpc.Dial(entryScheme+":///"+c.name,
+		grpc.WithResolvers(entryResolverBuilder{}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDisableServiceConfig(),
+		grpc.WithUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, _ *grpc.ClientConn, _ grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return c.Invoke(ctx, method, req, reply, opts...)
+		}),
+		grpc.WithStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, _ grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return c.NewStream(ctx, desc, method, opts...)
+		}),
+	)
+	if err != nil {
+		// 只使用内置的选项，不会出错
+		panic(err)
+	}
+	return cc
+}
+
+// entryScheme 连接池入口使用的 resolver scheme
+const entryScheme = "ego-entry"
+
+// entryResolverBuilder 不解析任何地址，连接池入口的请求都由拦截器转发，不会使用它的连接
+type entryResolverBuilder struct{}
+
+func (entryResolverBuilder) Build(resolver.Target, resolver.ClientConn, resolver.BuildOptions) (resolver.Resolver, error) {
+	return entryResolver{}, nil
+}
+
+func (entryResolverBuilder) Scheme() string {
+	return entryScheme
+}
+
+type entryResolver struct{}
+
+func (entryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (entryResolver) Close() {}
+
 // Error 错误信息
 func (c *Component) Error() error {
 	return c.err
 }
+
+// acquireConn 从连接池中选择连接并增加请求计数
+func (c *Component) acquireConn() (*refConn, error) {
+	for {
+		pool := c.pool.Load()
+		if pool == nil {
+			var err error
+			if pool, err = c.connect(); err != nil {
+				return nil, err
+			}
+		}
+		if conn := pool.pick(); conn.acquire() {
+			return conn, nil
+		}
+		// 旧连接正在关闭，新连接已经替换，重新获取
+	}
+}
+
+// connect 开启 EnableLazyDial 时建立连接，建立失败时返回错误，下一次请求时重试
+func (c *Component) connect() (*connPool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pool := c.pool.Load(); pool != nil {
+		return pool, nil
+	}
+	if !c.config.EnableLazyDial {
+		return nil, status.Errorf(codes.Unavailable, "grpc client %s not connected: %v", c.name, c.err)
+	}
+	startTime := time.Now()
+	ccs, err := c.dialPool(c.addr, c.balancerName)
+	if err != nil {
+		c.logger.Error("dial grpc server", elog.FieldErrKind("request err"), elog.FieldErr(err), elog.FieldKey(c.name), elog.FieldAddr(c.addr), elog.FieldCost(time.Since(startTime)))
+		return nil, status.Errorf(codes.Unavailable, "grpc client %s dial: %v", c.name, err)
+	}
+	c.logger.Info("dial grpc server", elog.FieldName(c.name), elog.FieldAddr(c.addr), elog.FieldCost(time.Since(startTime)))
+	pool := c.newConnPool(ccs)
+	c.pool.Store(pool)
+	return pool, nil
+}
+
+// Invoke 使用当前连接发起 unary 请求，实现 grpc.ClientConnInterface
+func (c *Component) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	conn, err := c.acquireConn()
+	if err != nil {
+		return err
+	}
+	defer conn.release()
+	return conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream 使用当前连接发起流式请求，实现 grpc.ClientConnInterface
+func (c *Component) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, err := c.acquireConn()
+	if err != nil {
+		return nil, err
+	}
+	s, err := conn.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		conn.release()
+		return s, err
+	}
+	return newMonitoredClientStream(ctx, s, desc, nil, func(err error) {
+		conn.release()
+	}), nil
+}
+
+// Ready 等待连接池中所有连接就绪，开启 EnableLazyDial 时会先建立连接
+// ctx 结束前没有就绪时返回连接当前的状态
+func (c *Component) Ready(ctx context.Context) error {
+	pool := c.pool.Load()
+	if pool == nil {
+		var err error
+		if pool, err = c.connect(); err != nil {
+			return err
+		}
+	}
+	for _, conn := range pool.conns {
+		for {
+			state := conn.GetState()
+			if state == connectivity.Ready {
+				break
+			}
+			if state == connectivity.Idle {
+				conn.Connect()
+			}
+			if state == connectivity.Shutdown || !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("grpc client %s not ready, conn %s state %s", c.name, conn.index, state)
+			}
+		}
+	}
+	return nil
+}
+
+// reloadConn Addr、BalancerName 变更后建立新的连接池并替换当前连接池，旧连接上的请求结束后再关闭旧连接
+// 新连接建立失败时继续使用旧连接
+func (c *Component) reloadConn(addr, balancerName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.addr == addr && c.balancerName == balancerName {
+		return
+	}
+	// 还没有建立连接，下次建立连接时使用新的地址
+	if c.pool.Load() == nil {
+		c.addr = addr
+		c.balancerName = balancerName
+		return
+	}
+	startTime := time.Now()
+	ccs, err := c.dialPool(addr, balancerName)
+	if err != nil {
+		c.logger.Error("reload grpc client conn", elog.FieldErrKind("request err"), elog.FieldErr(err), elog.FieldKey(c.name), elog.FieldAddr(addr), elog.FieldCost(time.Since(startTime)))
+		return
+	}
+	c.logger.Info("reload grpc client conn", elog.FieldName(c.name), elog.FieldAddr(addr), elog.String("balancerName", balancerName), elog.FieldCost(time.Since(startTime)))
+	c.addr = addr
+	c.balancerName = balancerName
+	if old := c.pool.Swap(c.newConnPool(ccs)); old != nil {
+		for _, conn := range old.conns {
+			go conn.drain(connDrainTimeout)
+		}
+	}
+}
+
+// Close 关闭连接池中所有连接，并停止监听证书、token 文件
+// 连接切换过时，原连接在切换时已经关闭
+func (c *Component) Close() error {
+	for _, closer := range c.closers {
+		_ = closer.Close()
+	}
+	if c.readinessName != "" {
+		ehealth.Unregister(c.readinessName)
+	}
+	err := c.ClientConn.Close()
+	pool := c.pool.Load()
+	if pool == nil {
+		return err
+	}
+	for _, conn := range pool.conns {
+		if closeErr := conn.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -6,9 +6,12 @@ import (
 	"log"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/proto"
 
@@ -83,3 +86,52 @@ func (g Greeter) SayHello(context context.Context, request *helloworld.HelloRequ
 func bufDialer(context.Context, string) (net.Conn, error) {
 	return svc.Listener().(*bufconn.Listener).Dial()
 }
+
+func TestComponent_LazyDial(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	helloworld.RegisterGreeterServer(server, &GreeterRetry{})
+
+	container := DefaultContainer()
+	container.config.EnableLazyDial = true
+	container.config.EnableReadinessCheck = true
+	cmp := container.Build(
+		WithName("lazy"),
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+	)
+	defer cmp.Close()
+	// 下游服务没有启动时也可以立即返回，ClientConn 可以直接使用
+	require.NotNil(t, cmp.ClientConn)
+	assert.Nil(t, cmp.pool.Load())
+	cli := helloworld.NewGreeterClient(cmp.ClientConn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.Error(t, cmp.Ready(ctx))
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	assert.NoError(t, cmp.Ready(ctx))
+	res, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello ego", res.Message)
+
+	// 切换连接后 ClientConn 使用新的连接
+	oldPool := cmp.pool.Load()
+	cmp.reloadConn("bufnet-reload", cmp.balancerName)
+	assert.NotSame(t, oldPool, cmp.pool.Load())
+	res, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello ego", res.Message)
+	assert.Eventually(t, func() bool {
+		return oldPool.conns[0].GetState() == connectivity.Shutdown
+	}, time.Second, 10*time.Millisecond)
+	res, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello ego", res.Message)
+}
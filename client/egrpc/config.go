@@ -1,10 +1,13 @@
 package egrpc
 
 import (
+	"io"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/keepalive"
 
 	"github.com/gotomicro/ego/core/util/xtime"
@@ -19,6 +22,8 @@ type Config struct {
 	OnFail                     string        // 失败后的处理方式，panic | error
 	DialTimeout                time.Duration // 连接超时，默认3s
 	ReadTimeout                time.Duration // 读超时，默认1s
+	StreamIdleTimeout          time.Duration // 流式请求的空闲超时，超过该时间没有收发消息则取消请求，默认0不限制
+	StreamMaxDuration          time.Duration // 流式请求的最大时长，默认0不限制
 	SlowLogThreshold           time.Duration // 慢日志记录的阈值，默认600ms
 	EnableBlock                bool          // 是否开启阻塞，默认开启
 	EnableOfficialGrpcLog      bool          // 是否开启官方grpc日志，默认关闭
@@ -33,10 +38,42 @@ type Config struct {
 	// EnableCPUUsage               bool          // 是否开启CPU利用率，默认开启
 	EnableServiceConfig          bool // 是否开启服务配置，默认开启
 	EnableFailOnNonTempDialError bool
-	MaxCallRecvMsgSize           int // 最大接收消息大小，默认4MB
+	MaxCallRecvMsgSize           int             // 最大接收消息大小，默认4MB
+	HedgingPolicies              []HedgingPolicy // 对冲请求策略，按方法配置，默认不开启
+	EnableTLS                    bool            // 是否开启 TLS，开启后忽略 EnableWithInsecure，默认不开启
+	TLSCertFile                  string          // mTLS 客户端证书，文件变化后自动重新加载
+	TLSKeyFile                   string          // mTLS 客户端私钥，文件变化后自动重新加载
+	TLSCAFiles                   []string        // 校验服务端证书的 CA，文件变化后自动重新加载，默认使用系统CA
+	TLSServerName                string          // 覆盖校验服务端证书使用的域名，默认使用连接地址
+	TLSInsecureSkipVerify        bool            // 是否跳过服务端证书校验，默认不跳过
+	TLSAllowedSANs               []string        // 允许的服务端证书 SAN（DNS、IP、URI），支持以*结尾的前缀匹配，例如 spiffe://example.org/ns/default/*，配置后不再校验域名
+	CredentialsType              string          // 每次请求携带的凭证类型，static | file | oauth2，默认不开启；开启 TLS 后凭证只在 TLS 连接上发送
+	CredentialsToken             string          // static: bearer token
+	CredentialsTokenFile         string          // file: token 文件，文件变化后自动重新加载
+	CredentialsTokenURL          string          // oauth2: 获取 token 的地址
+	CredentialsClientID          string          // oauth2: client id
+	CredentialsClientSecret      string          // oauth2: client secret
+	CredentialsScopes            []string        // oauth2: scopes
+	HedgingBudgetRatio           float64         // 对冲请求的预算，每个请求积累的对冲次数，默认0.1，即对冲请求最多为正常请求的10%
+	RetryMaxAttempts             int             // 失败后的最大请求次数（包含首次请求），默认0不重试
+	RetryStatusCodes             []string        // 需要重试的错误码，默认UNAVAILABLE
+	RetryBackoff                 time.Duration   // 重试的初始退避时间，按指数增长，默认100ms
+	EnableReloadConn             bool            // 配置变更时，Addr、BalancerName 变化是否平滑切换连接，默认不开启
+	PoolSize                     int             // 连接池大小，每个连接独立建立 HTTP/2 连接，默认1
+	PoolStrategy                 string          // 连接池选择连接的方式，round_robin | least_busy，默认round_robin
+	EnableLazyDial               bool            // 是否延迟建立连接，开启后忽略 EnableBlock，组件立即返回，在第一次请求或调用 Ready 时才建立连接，默认不开启
+	EnableReadinessCheck         bool            // 是否将连接状态注册为 ehealth 就绪检查，所有连接就绪时 /readyz 和 gRPC 服务状态才为就绪，默认不开启；可选的下游服务不建议开启
+	CachePolicies                []CachePolicy   // 响应缓存策略，按方法配置，只能用于只读的幂等方法，默认不开启
+	CacheSize                    int             // 响应缓存的最大条数，超过后淘汰最久未使用的缓存，默认1000
 
 	keepAlive   *keepalive.ClientParameters
 	dialOptions []grpc.DialOption
+	retryCodes  map[codes.Code]struct{}
+	recordFile  string       // 录制文件，设置后将请求录制到文件
+	closers     []io.Closer  // 随组件一起关闭的资源
+	mu          sync.RWMutex // mutex for ReadTimeout、SlowLogThreshold、EnableAccessInterceptor、EnableAccessInterceptorReq、EnableAccessInterceptorRes、RetryMaxAttempts、RetryStatusCodes、RetryBackoff、retryCodes
+
+	recordRedactMetadata []string // 录制时额外需要脱敏的 metadata key
 }
 
 // DefaultConfig defines grpc client default configuration
@@ -61,5 +98,11 @@ func DefaultConfig() *Config {
 		EnableServiceConfig:          true,
 		// EnableCPUUsage:               true,
 		MaxCallRecvMsgSize: DefaultMaxCallRecvMsgSize,
+		HedgingBudgetRatio: 0.1,
+		RetryStatusCodes:   []string{"UNAVAILABLE"},
+		RetryBackoff:       xtime.Duration("100ms"),
+		PoolSize:           1,
+		PoolStrategy:       PoolStrategyRoundRobin,
+		CacheSize:          1000,
 	}
 }
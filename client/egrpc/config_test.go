@@ -34,5 +34,11 @@ func TestDefaultConfig(t *testing.T) {
 		keepAlive:                    nil,
 		dialOptions:                  nil,
 		MaxCallRecvMsgSize:           DefaultMaxCallRecvMsgSize,
+		HedgingBudgetRatio:           0.1,
+		RetryStatusCodes:             []string{"UNAVAILABLE"},
+		RetryBackoff:                 xtime.Duration("100ms"),
+		PoolSize:                     1,
+		PoolStrategy:                 PoolStrategyRoundRobin,
+		CacheSize:                    1000,
 	}, DefaultConfig()))
 }
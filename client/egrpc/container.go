@@ -1,10 +1,15 @@
 package egrpc
 
 import (
+	"reflect"
+	"strings"
+	"sync"
+
 	"google.golang.org/grpc"
 
 	"github.com/gotomicro/ego/core/eapp"
 	"github.com/gotomicro/ego/core/econf"
+	"github.com/gotomicro/ego/core/ehealth"
 	"github.com/gotomicro/ego/core/elog"
 )
 
@@ -13,9 +18,11 @@ type Option func(c *Container)
 
 // Container defines a component instance.
 type Container struct {
-	config *Config
-	name   string
-	logger *elog.Component
+	config   *Config
+	name     string
+	logger   *elog.Component
+	snapshot map[string]interface{} // 上一次加载的配置，key 为小写，用于判断配置变更时哪些配置有变化
+	reloadMu sync.Mutex             // 配置变更的回调是并发执行的，reload 需要串行执行
 }
 
 // DefaultContainer returns an default container.
@@ -51,6 +58,7 @@ func (c *Container) Build(options ...Option) *Component {
 	}
 	// 默认日志
 	unaryInterceptors = append(unaryInterceptors, c.loggerUnaryClientInterceptor())
+	streamInterceptors = append(streamInterceptors, c.loggerStreamClientInterceptor())
 	if eapp.IsDevelopmentMode() {
 		unaryInterceptors = append(unaryInterceptors, c.debugUnaryClientInterceptor())
 	}
@@ -60,16 +68,126 @@ func (c *Container) Build(options ...Option) *Component {
 	}
 	if c.config.EnableTimeoutInterceptor {
 		unaryInterceptors = append(unaryInterceptors, c.timeoutUnaryClientInterceptor())
+		if c.config.StreamIdleTimeout > 0 || c.config.StreamMaxDuration > 0 {
+			streamInterceptors = append(streamInterceptors, c.timeoutStreamClientInterceptor())
+		}
 	}
 	if c.config.EnableMetricInterceptor {
 		unaryInterceptors = append(unaryInterceptors, c.metricUnaryClientInterceptor())
+		streamInterceptors = append(streamInterceptors, c.metricStreamClientInterceptor())
 	}
 	for _, option := range options {
 		option(c)
 	}
+	// 缓存命中时不再发送请求，所以放在录制、重试和对冲请求之前
+	if len(c.config.CachePolicies) > 0 {
+		unaryInterceptors = append(unaryInterceptors, c.cacheUnaryClientInterceptor())
+	}
+	// 录制放在重试和对冲请求之前，记录的是整个调用
+	if c.config.recordFile != "" {
+		rec := newRecorder(c.config.recordFile, c.config.recordRedactMetadata, c.logger)
+		unaryInterceptors = append(unaryInterceptors, c.recordUnaryClientInterceptor(rec))
+		streamInterceptors = append(streamInterceptors, c.recordStreamClientInterceptor(rec))
+	}
+	// 重试和对冲请求放在最后，使日志、监控记录的是整个调用，而不是每一次重试或对冲请求
+	// 重试策略可以动态修改，所以总是添加重试拦截器，未开启时直接调用
+	c.config.retryCodes = parseRetryCodes(c.config.RetryStatusCodes, c.logger)
+	unaryInterceptors = append(unaryInterceptors, c.retryUnaryClientInterceptor())
+	if len(c.config.HedgingPolicies) > 0 {
+		unaryInterceptors = append(unaryInterceptors, c.hedgingUnaryClientInterceptor())
+	}
 	c.config.dialOptions = append(c.config.dialOptions,
 		grpc.WithChainStreamInterceptor(streamInterceptors...),
 		grpc.WithChainUnaryInterceptor(unaryInterceptors...),
 	)
-	return newComponent(c.name, c.config, c.logger)
+	component := newComponent(c.name, c.config, c.logger)
+	if c.config.EnableReadinessCheck {
+		name := c.name
+		if name == "" {
+			name = c.config.Addr
+		}
+		component.readinessName = PackageName + "." + name
+		ehealth.Register(component.readinessName, component.Ready)
+	}
+	if c.name != "" {
+		c.snapshot = lowerKeys(econf.GetStringMap(c.name))
+		econf.OnChange(func(newConf *econf.Configuration) {
+			c.reload(newConf, component)
+		})
+	}
+	return component
+}
+
+// reload 配置变更后更新可以动态修改的配置，不需要重新建立连接
+// 只更新配置文件中存在并且和上一次加载时不同的配置，避免覆盖通过 Option 设置的配置
+// 开启 EnableReloadConn 后，Addr、BalancerName 变更时平滑切换连接
+func (c *Container) reload(newConf *econf.Configuration, component *Component) {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+	config := DefaultConfig()
+	if err := newConf.UnmarshalKey(c.name, config); err != nil {
+		return
+	}
+	snapshot := lowerKeys(newConf.GetStringMap(c.name))
+	changed := func(key string) bool {
+		value, ok := snapshot[key]
+		if !ok {
+			return false
+		}
+		old, ok := c.snapshot[key]
+		return !ok || !reflect.DeepEqual(old, value)
+	}
+	defer func() {
+		c.snapshot = snapshot
+	}()
+
+	c.config.mu.Lock()
+	if changed("readtimeout") {
+		c.config.ReadTimeout = config.ReadTimeout
+	}
+	if changed("slowlogthreshold") {
+		c.config.SlowLogThreshold = config.SlowLogThreshold
+	}
+	if changed("enableaccessinterceptor") {
+		c.config.EnableAccessInterceptor = config.EnableAccessInterceptor
+	}
+	if changed("enableaccessinterceptorreq") {
+		c.config.EnableAccessInterceptorReq = config.EnableAccessInterceptorReq
+	}
+	if changed("enableaccessinterceptorres") {
+		c.config.EnableAccessInterceptorRes = config.EnableAccessInterceptorRes
+	}
+	if changed("retrymaxattempts") {
+		c.config.RetryMaxAttempts = config.RetryMaxAttempts
+	}
+	if changed("retrystatuscodes") {
+		c.config.RetryStatusCodes = config.RetryStatusCodes
+		c.config.retryCodes = parseRetryCodes(config.RetryStatusCodes, c.logger)
+	}
+	if changed("retrybackoff") {
+		c.config.RetryBackoff = config.RetryBackoff
+	}
+	c.config.mu.Unlock()
+
+	if c.config.EnableReloadConn && component.err == nil && (changed("addr") || changed("balancername")) {
+		component.mu.Lock()
+		addr, balancerName := component.addr, component.balancerName
+		component.mu.Unlock()
+		if changed("addr") {
+			addr = config.Addr
+		}
+		if changed("balancername") {
+			balancerName = config.BalancerName
+		}
+		component.reloadConn(addr, balancerName)
+	}
+}
+
+// lowerKeys 将配置的 key 转为小写，配置解析时 key 不区分大小写
+func lowerKeys(m map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[strings.ToLower(k)] = v
+	}
+	return res
 }
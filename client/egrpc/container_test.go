@@ -1,9 +1,21 @@
 package egrpc
 
 import (
+	"context"
+	"log"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/gotomicro/ego/internal/test/helloworld"
 )
 
 func TestDefaultContainer(t *testing.T) {
@@ -12,3 +24,105 @@ func TestDefaultContainer(t *testing.T) {
 		c.Build()
 	})
 }
+
+func TestContainer_reload(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	helloworld.RegisterGreeterServer(server, &GreeterRetry{})
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	defer server.Stop()
+
+	container := DefaultContainer()
+	container.config.EnableReloadConn = true
+	cmp := container.Build(
+		WithName("grpc.test"),
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+	)
+	defer cmp.Close()
+	cli := helloworld.NewGreeterClient(cmp)
+	_, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	oldConn := cmp.pool.Load().conns[0]
+
+	conf := econf.New()
+	require.NoError(t, conf.LoadFromReader(strings.NewReader(`
+[grpc.test]
+addr = "passthrough:///bufnet2"
+readTimeout = "3s"
+slowLogThreshold = "1s"
+enableAccessInterceptor = true
+enableAccessInterceptorReq = true
+retryMaxAttempts = 2
+`), toml.Unmarshal))
+	container.reload(conf, cmp)
+
+	container.config.mu.RLock()
+	assert.Equal(t, 3*time.Second, container.config.ReadTimeout)
+	assert.Equal(t, time.Second, container.config.SlowLogThreshold)
+	assert.True(t, container.config.EnableAccessInterceptor)
+	assert.True(t, container.config.EnableAccessInterceptorReq)
+	assert.False(t, container.config.EnableAccessInterceptorRes)
+	assert.Equal(t, 2, container.config.RetryMaxAttempts)
+	container.config.mu.RUnlock()
+
+	// 切换到新连接，旧连接上没有请求后关闭
+	newConn := cmp.pool.Load().conns[0]
+	assert.NotSame(t, oldConn, newConn)
+	assert.Equal(t, "passthrough:///bufnet2", newConn.Target())
+	_, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return oldConn.GetState() == connectivity.Shutdown
+	}, time.Second, 10*time.Millisecond)
+
+	// 地址没有变化时不切换连接
+	container.reload(conf, cmp)
+	assert.Same(t, newConn, cmp.pool.Load().conns[0])
+}
+
+func TestContainer_reloadKeepOptions(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+	container := DefaultContainer()
+	cmp := container.Build(
+		WithName("grpc.option"),
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+		WithReadTimeout(5*time.Second),
+	)
+	defer cmp.Close()
+
+	// 无关的配置变更不会覆盖通过 Option 设置的配置
+	conf := econf.New()
+	require.NoError(t, conf.LoadFromReader(strings.NewReader(`
+[grpc.option]
+dialTimeout = "2s"
+[other]
+readTimeout = "3s"
+`), toml.Unmarshal))
+	container.reload(conf, cmp)
+	container.config.mu.RLock()
+	assert.Equal(t, 5*time.Second, container.config.ReadTimeout)
+	assert.Equal(t, DefaultConfig().SlowLogThreshold, container.config.SlowLogThreshold)
+	container.config.mu.RUnlock()
+
+	// 只更新变化的配置
+	require.NoError(t, conf.LoadFromReader(strings.NewReader(`
+[grpc.option]
+SlowLogThreshold = "2s"
+`), toml.Unmarshal))
+	container.reload(conf, cmp)
+	container.config.mu.RLock()
+	assert.Equal(t, 5*time.Second, container.config.ReadTimeout)
+	assert.Equal(t, 2*time.Second, container.config.SlowLogThreshold)
+	container.config.mu.RUnlock()
+}
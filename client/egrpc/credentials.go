@@ -0,0 +1,238 @@
+package egrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/internal/filewatch"
+)
+
+const (
+	// CredentialsTypeStatic 静态 bearer token
+	CredentialsTypeStatic = "static"
+	// CredentialsTypeFile 从文件中读取 token，文件变化后自动重新加载
+	CredentialsTypeFile = "file"
+	// CredentialsTypeOAuth2 OAuth2 client credentials 模式获取 token
+	CredentialsTypeOAuth2 = "oauth2"
+)
+
+// oauth2ExpiryDelta token 在过期前提前刷新的时间
+const oauth2ExpiryDelta = 10 * time.Second
+
+// newPerRPCCredentials 根据配置创建每次请求携带的凭证
+func newPerRPCCredentials(config *Config, logger *elog.Component) (credentials.PerRPCCredentials, error) {
+	switch config.CredentialsType {
+	case "":
+		return nil, nil
+	case CredentialsTypeStatic:
+		return NewStaticTokenCredentials(config.CredentialsToken, config.EnableTLS), nil
+	case CredentialsTypeFile:
+		return NewFileTokenCredentials(config.CredentialsTokenFile, config.EnableTLS, logger)
+	case CredentialsTypeOAuth2:
+		return NewOAuth2Credentials(OAuth2Config{
+			TokenURL:     config.CredentialsTokenURL,
+			ClientID:     config.CredentialsClientID,
+			ClientSecret: config.CredentialsClientSecret,
+			Scopes:       config.CredentialsScopes,
+		}, config.EnableTLS), nil
+	default:
+		return nil, fmt.Errorf("unknown credentials type %q", config.CredentialsType)
+	}
+}
+
+func bearerMetadata(token string) map[string]string {
+	return map[string]string{"authorization": "Bearer " + token}
+}
+
+// StaticTokenCredentials 静态 bearer token
+type StaticTokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+// NewStaticTokenCredentials 创建静态 bearer token 凭证，requireTLS 为 true 时只允许在 TLS 连接上发送
+func NewStaticTokenCredentials(token string, requireTLS bool) *StaticTokenCredentials {
+	return &StaticTokenCredentials{token: token, requireTLS: requireTLS}
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (s *StaticTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return bearerMetadata(s.token), nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (s *StaticTokenCredentials) RequireTransportSecurity() bool {
+	return s.requireTLS
+}
+
+// FileTokenCredentials 从文件中读取 bearer token，文件变化后自动重新加载
+// 适用于 k8s projected service account token 这类定期轮换的 token
+type FileTokenCredentials struct {
+	path       string
+	requireTLS bool
+	logger     *elog.Component
+	mu         sync.RWMutex
+	token      string
+	watcher    *filewatch.Watcher
+}
+
+// NewFileTokenCredentials 创建文件 token 凭证
+func NewFileTokenCredentials(path string, requireTLS bool, logger *elog.Component) (*FileTokenCredentials, error) {
+	f := &FileTokenCredentials{
+		path:       path,
+		requireTLS: requireTLS,
+		logger:     logger,
+	}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	watcher, err := filewatch.Watch([]string{path}, f.reload, logger)
+	if err != nil {
+		return nil, fmt.Errorf("watch token file fail, %w", err)
+	}
+	f.watcher = watcher
+	return f, nil
+}
+
+func (f *FileTokenCredentials) load() error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("read token file fail, %w", err)
+	}
+	f.mu.Lock()
+	f.token = strings.TrimSpace(string(data))
+	f.mu.Unlock()
+	return nil
+}
+
+// reload 重新加载失败时继续使用旧的 token
+func (f *FileTokenCredentials) reload() {
+	if err := f.load(); err != nil {
+		f.logger.Error("reload token file fail", elog.FieldErr(err), elog.FieldName(f.path))
+	}
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (f *FileTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return bearerMetadata(f.token), nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (f *FileTokenCredentials) RequireTransportSecurity() bool {
+	return f.requireTLS
+}
+
+// Close 停止监听 token 文件
+func (f *FileTokenCredentials) Close() error {
+	return f.watcher.Close()
+}
+
+// OAuth2Config OAuth2 client credentials 配置
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	HTTPClient   *http.Client // 请求 token 使用的 http client，默认超时5s
+}
+
+// OAuth2Credentials 使用 OAuth2 client credentials 模式获取 token，缓存到过期前再刷新
+type OAuth2Credentials struct {
+	config     OAuth2Config
+	requireTLS bool
+	mu         sync.Mutex
+	token      string
+	expiry     time.Time
+}
+
+// NewOAuth2Credentials 创建 OAuth2 client credentials 凭证
+func NewOAuth2Credentials(config OAuth2Config, requireTLS bool) *OAuth2Credentials {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &OAuth2Credentials{config: config, requireTLS: requireTLS}
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (o *OAuth2Credentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := o.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bearerMetadata(token), nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (o *OAuth2Credentials) RequireTransportSecurity() bool {
+	return o.requireTLS
+}
+
+// Token 返回缓存的 token，过期时重新获取
+func (o *OAuth2Credentials) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token != "" && (o.expiry.IsZero() || time.Now().Add(oauth2ExpiryDelta).Before(o.expiry)) {
+		return o.token, nil
+	}
+	token, expiresIn, err := o.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	o.token = token
+	o.expiry = time.Time{}
+	if expiresIn > 0 {
+		o.expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return o.token, nil
+}
+
+func (o *OAuth2Credentials) fetch(ctx context.Context) (string, int64, error) {
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+	}
+	if len(o.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.config.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.config.ClientID), url.QueryEscape(o.config.ClientSecret))
+	resp, err := o.config.HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 fetch token fail, %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 read token fail, %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("oauth2 fetch token fail, status: %d, body: %s", resp.StatusCode, body)
+	}
+	var tokenRes struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenRes); err != nil {
+		return "", 0, fmt.Errorf("oauth2 decode token fail, %w", err)
+	}
+	if tokenRes.AccessToken == "" {
+		return "", 0, fmt.Errorf("oauth2 empty access token")
+	}
+	return tokenRes.AccessToken, tokenRes.ExpiresIn, nil
+}
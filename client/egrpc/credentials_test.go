@@ -0,0 +1,99 @@
+package egrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotomicro/ego/core/elog"
+)
+
+func TestStaticTokenCredentials(t *testing.T) {
+	creds := NewStaticTokenCredentials("token", true)
+	md, err := creds.GetRequestMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token", md["authorization"])
+	assert.True(t, creds.RequireTransportSecurity())
+}
+
+func TestFileTokenCredentials(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(file, []byte("token1\n"), 0600))
+	creds, err := NewFileTokenCredentials(file, false, elog.DefaultLogger)
+	require.NoError(t, err)
+	defer creds.Close()
+
+	md, err := creds.GetRequestMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token1", md["authorization"])
+
+	require.NoError(t, os.WriteFile(file, []byte("token2"), 0600))
+	assert.Eventually(t, func() bool {
+		md, _ := creds.GetRequestMetadata(context.Background())
+		return md["authorization"] == "Bearer token2"
+	}, 3*time.Second, 50*time.Millisecond)
+
+	_, err = NewFileTokenCredentials(filepath.Join(t.TempDir(), "not-exist"), false, elog.DefaultLogger)
+	assert.Error(t, err)
+}
+
+func TestOAuth2Credentials(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		id, secret, _ := r.BasicAuth()
+		assert.Equal(t, "id", id)
+		assert.Equal(t, "secret", secret)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"oauth-token","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer ts.Close()
+
+	creds := NewOAuth2Credentials(OAuth2Config{
+		TokenURL:     ts.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	}, false)
+	for i := 0; i < 3; i++ {
+		md, err := creds.GetRequestMetadata(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer oauth-token", md["authorization"])
+	}
+	// token 被缓存
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// token 即将过期时重新获取
+	creds.expiry = time.Now().Add(time.Second)
+	_, err := creds.GetRequestMetadata(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNewPerRPCCredentials(t *testing.T) {
+	cfg := DefaultConfig()
+	creds, err := newPerRPCCredentials(cfg, elog.DefaultLogger)
+	assert.NoError(t, err)
+	assert.Nil(t, creds)
+
+	cfg.CredentialsType = CredentialsTypeStatic
+	cfg.CredentialsToken = "token"
+	creds, err = newPerRPCCredentials(cfg, elog.DefaultLogger)
+	assert.NoError(t, err)
+	assert.IsType(t, &StaticTokenCredentials{}, creds)
+
+	cfg.CredentialsType = "unknown"
+	_, err = newPerRPCCredentials(cfg, elog.DefaultLogger)
+	assert.Error(t, err)
+}
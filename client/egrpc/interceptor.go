@@ -299,7 +299,10 @@ func (c *Container) timeoutUnaryClientInterceptor() grpc.UnaryClientInterceptor
 		_, ok := ctx.Deadline()
 		if !ok {
 			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeoutCause(ctx, c.config.ReadTimeout, fmt.Errorf("grpc client read timeout"))
+			c.config.mu.RLock()
+			readTimeout := c.config.ReadTimeout
+			c.config.mu.RUnlock()
+			ctx, cancel = context.WithTimeoutCause(ctx, readTimeout, fmt.Errorf("grpc client read timeout"))
 			defer cancel()
 		}
 		return invoker(ctx, method, req, reply, cc, opts...)
@@ -314,7 +317,15 @@ func (c *Container) loggerUnaryClientInterceptor() grpc.UnaryClientInterceptor {
 		var event = "normal"
 		var isSlowLog = false
 
-		if c.config.EnableAccessInterceptor {
+		// 日志相关配置可以动态修改，每次请求读取一次
+		c.config.mu.RLock()
+		enableAccess := c.config.EnableAccessInterceptor
+		enableAccessReq := c.config.EnableAccessInterceptorReq
+		enableAccessRes := c.config.EnableAccessInterceptorRes
+		slowLogThreshold := c.config.SlowLogThreshold
+		c.config.mu.RUnlock()
+
+		if enableAccess {
 			fields = make([]elog.Field, 0, 20+transport.CustomContextKeysLength())
 		}
 
@@ -324,7 +335,7 @@ func (c *Container) loggerUnaryClientInterceptor() grpc.UnaryClientInterceptor {
 				// 替换context
 				ctx = metadata.AppendToOutgoingContext(ctx, key, value)
 				// grpc metadata 存在同一个 key set 多次，客户端可通过日志排查这种错误使用。
-				if c.config.EnableAccessInterceptor {
+				if enableAccess {
 					if md, ok := metadata.FromOutgoingContext(ctx); ok {
 						fields = append(fields, elog.FieldCustomKeyValue(key, strings.Join(md[strings.ToLower(key)], ";")))
 					}
@@ -334,12 +345,12 @@ func (c *Container) loggerUnaryClientInterceptor() grpc.UnaryClientInterceptor {
 
 		err = invoker(ctx, method, req, res, cc, opts...)
 		cost := time.Since(beg)
-		if c.config.SlowLogThreshold > time.Duration(0) && cost > c.config.SlowLogThreshold {
+		if slowLogThreshold > time.Duration(0) && cost > slowLogThreshold {
 			isSlowLog = true
 			event = "slow"
 		}
 		// 开启了AccessInterceptor或发生错误时记日志
-		if c.config.EnableAccessInterceptor || err != nil || isSlowLog {
+		if enableAccess || err != nil || isSlowLog {
 			spbStatus := ecode.Convert(err)
 			httpStatusCode := ecode.GrpcToHTTPStatusCode(spbStatus.Code())
 			fields = append(fields,
@@ -358,7 +369,7 @@ func (c *Container) loggerUnaryClientInterceptor() grpc.UnaryClientInterceptor {
 				fields = append(fields, elog.FieldTid(etrace.ExtractTraceID(ctx)))
 			}
 
-			if c.config.EnableAccessInterceptorReq {
+			if enableAccessReq {
 				var reqMap = map[string]any{
 					"payload": xstring.JSON(req),
 				}
@@ -367,7 +378,7 @@ func (c *Container) loggerUnaryClientInterceptor() grpc.UnaryClientInterceptor {
 				}
 				fields = append(fields, elog.Any("req", reqMap))
 			}
-			if c.config.EnableAccessInterceptorRes {
+			if enableAccessRes {
 				fields = append(fields, elog.Any("res", json.RawMessage(xstring.JSON(res))))
 			}
 
@@ -386,7 +397,7 @@ func (c *Container) loggerUnaryClientInterceptor() grpc.UnaryClientInterceptor {
 				// isSlowLog == true，表示为慢日志时，记录日志
 				c.logger.Warn("access", fields...)
 			} else {
-				// c.config.EnableAccessInterceptor == true，表示开启了记录Access日志时，记录日志
+				// enableAccess == true，表示开启了记录Access日志时，记录日志
 				c.logger.Info("access", fields...)
 			}
 		}
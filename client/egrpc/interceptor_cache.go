@@ -0,0 +1,182 @@
+package egrpc
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gotomicro/ego/core/emetric"
+)
+
+// CachePolicy 响应缓存策略，只能用于只读的幂等方法
+// 缓存 key 默认不包含 metadata，响应和调用方身份、租户等 metadata 相关时需要配置 MetadataKeys
+type CachePolicy struct {
+	Method       string        // 方法名，例如 /helloworld.Greeter/SayHello
+	TTL          time.Duration // 缓存时间，默认1s
+	MetadataKeys []string      // 缓存 key 包含的 outgoing metadata，例如 authorization、x-tenant-id
+}
+
+// lruCache 带过期时间的 LRU 缓存
+type lruCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key      string
+	value    proto.Message
+	expireAt time.Time
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get 返回没有过期的缓存，过期的缓存会被删除
+func (l *lruCache) get(key string) (proto.Message, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expireAt) {
+		l.ll.Remove(elem)
+		delete(l.items, key)
+		return nil, false
+	}
+	l.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set 写入缓存，超过最大条数时淘汰最久未使用的缓存
+func (l *lruCache) set(key string, value proto.Message, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expireAt = time.Now().Add(ttl)
+		l.ll.MoveToFront(elem)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{key: key, value: value, expireAt: time.Now().Add(ttl)})
+	for l.ll.Len() > l.size {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// len 缓存条数，包括已经过期但还没有被删除的缓存
+func (l *lruCache) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
+
+// cacheUnaryClientInterceptor 缓存配置的方法的响应，缓存 key 为方法名、请求的确定性序列化结果和 MetadataKeys 的值
+// 相同请求并发调用时只发送一次请求，共享的请求使用独立的 context，保留链路和 metadata，超时时间为 ReadTimeout，
+// 某个调用方取消或者超时不影响其他调用方
+func (c *Container) cacheUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	policies := make(map[string]CachePolicy, len(c.config.CachePolicies))
+	for _, policy := range c.config.CachePolicies {
+		if policy.TTL <= 0 {
+			policy.TTL = time.Second
+		}
+		policies[policy.Method] = policy
+	}
+	size := c.config.CacheSize
+	if size <= 0 {
+		size = 1000
+	}
+	cache := newLRUCache(size)
+	var group singleflight.Group
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		policy, ok := policies[method]
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		reqMsg, ok := req.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		replyMsg, ok := reply.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(reqMsg)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		key := cacheKey(ctx, method, payload, policy.MetadataKeys)
+
+		if cached, ok := cache.get(key); ok {
+			emetric.CacheHandleCounter.Inc(emetric.TypeGRPCUnary, c.name, method, "hit")
+			proto.Reset(replyMsg)
+			proto.Merge(replyMsg, cached)
+			return nil
+		}
+		emetric.CacheHandleCounter.Inc(emetric.TypeGRPCUnary, c.name, method, "miss")
+		ch := group.DoChan(key, func() (interface{}, error) {
+			c.config.mu.RLock()
+			timeout := c.config.ReadTimeout
+			c.config.mu.RUnlock()
+			sharedCtx := context.WithoutCancel(ctx)
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				sharedCtx, cancel = context.WithTimeout(sharedCtx, timeout)
+				defer cancel()
+			}
+			res := replyMsg.ProtoReflect().New().Interface()
+			if err := invoker(sharedCtx, method, req, res, cc, opts...); err != nil {
+				return nil, err
+			}
+			cache.set(key, res, policy.TTL)
+			return res, nil
+		})
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case res := <-ch:
+			if res.Err != nil {
+				return res.Err
+			}
+			// 缓存的响应会被多个请求共享，只能拷贝不能直接返回
+			proto.Reset(replyMsg)
+			proto.Merge(replyMsg, res.Val.(proto.Message))
+			return nil
+		}
+	}
+}
+
+// cacheKey 缓存 key 为方法名、请求的序列化结果和 metadataKeys 的值
+func cacheKey(ctx context.Context, method string, payload []byte, metadataKeys []string) string {
+	var b strings.Builder
+	b.WriteString(method)
+	b.WriteString("\x00")
+	b.Write(payload)
+	if len(metadataKeys) > 0 {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		for _, k := range metadataKeys {
+			b.WriteString("\x00")
+			b.WriteString(strings.Join(md.Get(k), ","))
+		}
+	}
+	return b.String()
+}
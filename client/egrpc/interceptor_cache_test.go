@@ -0,0 +1,180 @@
+package egrpc
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/gotomicro/ego/internal/test/helloworld"
+)
+
+func TestCacheUnaryClientInterceptor(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	greeter := &GreeterCache{}
+	helloworld.RegisterGreeterServer(server, greeter)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	defer server.Stop()
+	ts := httptest.NewServer(promhttp.Handler())
+	defer ts.Close()
+
+	cmp := DefaultContainer().Build(
+		WithName("cache"),
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+		WithCachePolicy(CachePolicy{Method: "/helloworld.Greeter/SayHello", TTL: 200 * time.Millisecond}),
+	)
+	defer cmp.Close()
+	cli := helloworld.NewGreeterClient(cmp)
+
+	for i := 0; i < 3; i++ {
+		res, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hello ego", res.Message)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&greeter.calls))
+
+	// 命中缓存时先清空 reply，不保留 reply 原有的内容
+	reply := &helloworld.HelloResponse{}
+	reply.ProtoReflect().SetUnknown(protowire.AppendVarint(protowire.AppendTag(nil, 99, protowire.VarintType), 1))
+	require.NoError(t, cmp.Invoke(context.Background(), "/helloworld.Greeter/SayHello", &helloworld.HelloRequest{Name: "ego"}, reply))
+	assert.Equal(t, "Hello ego", reply.Message)
+	assert.Empty(t, reply.ProtoReflect().GetUnknown())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&greeter.calls))
+
+	// 不同的请求不使用缓存
+	_, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "other"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&greeter.calls))
+
+	// 过期后重新请求
+	time.Sleep(250 * time.Millisecond)
+	_, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&greeter.calls))
+
+	// 并发的相同请求只发送一次
+	time.Sleep(250 * time.Millisecond)
+	greeter.delay = 100 * time.Millisecond
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+			assert.NoError(t, err)
+			assert.Equal(t, "Hello ego", res.Message)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&greeter.calls))
+
+	res, err := ts.Client().Get(ts.URL)
+	require.NoError(t, err)
+	text, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Contains(t, string(text), `ego_cache_handle_total{action="/helloworld.Greeter/SayHello",code="hit",name="cache",type="unary"} 3`)
+}
+
+func TestCacheUnaryClientInterceptorShared(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	greeter := &GreeterCache{delay: 200 * time.Millisecond}
+	helloworld.RegisterGreeterServer(server, greeter)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	defer server.Stop()
+
+	cmp := DefaultContainer().Build(
+		WithName("cache-shared"),
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+		WithCachePolicy(CachePolicy{Method: "/helloworld.Greeter/SayHello", TTL: time.Minute, MetadataKeys: []string{"x-tenant-id"}}),
+	)
+	defer cmp.Close()
+	cli := helloworld.NewGreeterClient(cmp)
+	tenantCtx := func(tenant string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", tenant)
+	}
+
+	// 第一个调用方超时不影响共享同一个请求的其他调用方
+	ctx, cancel := context.WithTimeout(tenantCtx("t1"), 50*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := cli.SayHello(ctx, &helloworld.HelloRequest{Name: "ego"})
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	res, err := cli.SayHello(tenantCtx("t1"), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello ego", res.Message)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&greeter.calls))
+
+	// 不同租户不共享缓存
+	_, err = cli.SayHello(tenantCtx("t2"), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&greeter.calls))
+	_, err = cli.SayHello(tenantCtx("t1"), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&greeter.calls))
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := newLRUCache(2)
+	cache.set("a", &helloworld.HelloResponse{Message: "a"}, time.Minute)
+	cache.set("b", &helloworld.HelloResponse{Message: "b"}, time.Minute)
+	_, ok := cache.get("a")
+	assert.True(t, ok)
+	// a 刚被访问过，淘汰 b
+	cache.set("c", &helloworld.HelloResponse{Message: "c"}, time.Minute)
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.len())
+
+	cache.set("d", &helloworld.HelloResponse{Message: "d"}, -time.Second)
+	_, ok = cache.get("d")
+	assert.False(t, ok)
+}
+
+// GreeterCache 记录请求次数
+type GreeterCache struct {
+	calls int32
+	delay time.Duration
+	helloworld.UnimplementedGreeterServer
+}
+
+// SayHello ...
+func (g *GreeterCache) SayHello(ctx context.Context, request *helloworld.HelloRequest) (*helloworld.HelloResponse, error) {
+	atomic.AddInt32(&g.calls, 1)
+	time.Sleep(g.delay)
+	return &helloworld.HelloResponse{Message: "Hello " + request.Name}, nil
+}
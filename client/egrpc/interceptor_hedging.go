@@ -0,0 +1,228 @@
+package egrpc
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/internal/ecode"
+)
+
+const (
+	// hedgingLatencyWindow 用于计算延迟分位数的样本数量
+	hedgingLatencyWindow = 1024
+	// hedgingLatencyMinSamples 样本数量不足时，使用固定的 Delay
+	hedgingLatencyMinSamples = 32
+	// hedgingBudgetMaxTokens 对冲预算桶的容量
+	hedgingBudgetMaxTokens = 10
+	// hedgingFallbackDelay 没有配置 Delay，并且没有可用的延迟分位数时的等待时间，避免立即发送对冲请求
+	hedgingFallbackDelay = 100 * time.Millisecond
+)
+
+// HedgingPolicy 对冲请求策略
+// 首次请求发出 Delay（或者延迟分位数）后仍未返回，向其他后端发送相同的请求，取第一个成功的响应并取消其余请求
+type HedgingPolicy struct {
+	Method              string        // 方法全名，例如 /helloworld.Greeter/SayHello
+	Delay               time.Duration // 发送下一个对冲请求前的固定等待时间，使用 DelayPercentile 时作为样本不足时的等待时间，默认100ms
+	DelayPercentile     float64       // 使用客户端观测到的延迟分位数作为等待时间，例如0.95，默认0表示使用Delay
+	MaxAttempts         int           // 最多发送的请求数量（包含首次请求），默认2
+	NonFatalStatusCodes []string      // 收到这些错误码时立即发送下一个对冲请求，其余错误码直接返回，例如UNAVAILABLE，默认UNAVAILABLE
+}
+
+// hedgingBudget 对冲预算，每个正常请求积累 ratio 个 token，每个对冲请求消耗1个 token
+type hedgingBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+func newHedgingBudget(ratio float64) *hedgingBudget {
+	return &hedgingBudget{
+		ratio:  ratio,
+		tokens: hedgingBudgetMaxTokens,
+	}
+}
+
+func (b *hedgingBudget) deposit() {
+	b.mu.Lock()
+	b.tokens = math.Min(b.tokens+b.ratio, hedgingBudgetMaxTokens)
+	b.mu.Unlock()
+}
+
+func (b *hedgingBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// latencyWindow 记录最近成功请求的耗时，用于计算分位数
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) observe(d time.Duration) {
+	w.mu.Lock()
+	if len(w.samples) < hedgingLatencyWindow {
+		w.samples = append(w.samples, d)
+	} else {
+		w.samples[w.next] = d
+		w.next = (w.next + 1) % hedgingLatencyWindow
+	}
+	w.mu.Unlock()
+}
+
+// percentile 样本不足时返回false
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	if len(w.samples) < hedgingLatencyMinSamples {
+		w.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	w.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx], true
+}
+
+type hedgingMethod struct {
+	policy   HedgingPolicy
+	nonFatal map[codes.Code]struct{}
+	latency  *latencyWindow
+}
+
+func newHedgingMethod(policy HedgingPolicy, logger *elog.Component) *hedgingMethod {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 2
+	}
+	nonFatal := make(map[codes.Code]struct{})
+	for _, name := range policy.NonFatalStatusCodes {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(`"` + name + `"`)); err != nil {
+			logger.Warn("unknown hedging status code", elog.FieldMethod(policy.Method), elog.FieldValue(name))
+			continue
+		}
+		nonFatal[code] = struct{}{}
+	}
+	if len(policy.NonFatalStatusCodes) == 0 {
+		nonFatal[codes.Unavailable] = struct{}{}
+	}
+	return &hedgingMethod{
+		policy:   policy,
+		nonFatal: nonFatal,
+		latency:  &latencyWindow{},
+	}
+}
+
+// delay 返回发送下一个对冲请求前的等待时间
+func (h *hedgingMethod) delay() time.Duration {
+	if h.policy.DelayPercentile > 0 {
+		if d, ok := h.latency.percentile(h.policy.DelayPercentile); ok && d > 0 {
+			return d
+		}
+	}
+	if h.policy.Delay <= 0 {
+		return hedgingFallbackDelay
+	}
+	return h.policy.Delay
+}
+
+type hedgingResult struct {
+	reply proto.Message
+	err   error
+}
+
+// hedgingUnaryClientInterceptor 对配置了对冲策略的方法发送对冲请求
+func (c *Container) hedgingUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	methods := make(map[string]*hedgingMethod, len(c.config.HedgingPolicies))
+	for _, policy := range c.config.HedgingPolicies {
+		methods[policy.Method] = newHedgingMethod(policy, c.logger)
+	}
+	budget := newHedgingBudget(c.config.HedgingBudgetRatio)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		h, ok := methods[method]
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		replyMsg, ok := reply.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		budget.deposit()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan hedgingResult, h.policy.MaxAttempts)
+		attempt := func() {
+			res := replyMsg.ProtoReflect().New().Interface()
+			beg := time.Now()
+			err := invoker(ctx, method, req, res, cc, opts...)
+			if err == nil {
+				h.latency.observe(time.Since(beg))
+			}
+			results <- hedgingResult{reply: res, err: err}
+		}
+
+		go attempt()
+		sent, inflight := 1, 1
+		timer := time.NewTimer(h.delay())
+		defer timer.Stop()
+
+		var lastErr error
+		for {
+			select {
+			case res := <-results:
+				inflight--
+				if res.err == nil {
+					proto.Reset(replyMsg)
+					proto.Merge(replyMsg, res.reply)
+					return nil
+				}
+				lastErr = res.err
+				// 致命错误直接返回，不再等待其他请求
+				if _, nonFatal := h.nonFatal[ecode.Convert(res.err).Code()]; !nonFatal {
+					return res.err
+				}
+				if ctx.Err() == nil && sent < h.policy.MaxAttempts && budget.withdraw() {
+					go attempt()
+					sent++
+					inflight++
+					continue
+				}
+				if inflight == 0 {
+					return lastErr
+				}
+			case <-timer.C:
+				if ctx.Err() == nil && sent < h.policy.MaxAttempts && budget.withdraw() {
+					go attempt()
+					sent++
+					inflight++
+					timer.Reset(h.delay())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package egrpc
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/internal/test/helloworld"
+)
+
+func TestHedging(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	greeter := &GreeterHedging{}
+	helloworld.RegisterGreeterServer(server, greeter)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	cmp := DefaultContainer().Build(
+		WithBufnetServerListener(listener),
+		WithHedgingPolicy(HedgingPolicy{
+			Method: "/helloworld.Greeter/SayHello",
+			Delay:  50 * time.Millisecond,
+		}),
+	)
+	cli := helloworld.NewGreeterClient(cmp.ClientConn)
+	begin := time.Now()
+	res, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "Ego"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Ego", res.Message)
+	assert.True(t, time.Since(begin) < 500*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&greeter.calls))
+}
+
+func TestHedgingBudget(t *testing.T) {
+	budget := newHedgingBudget(0.5)
+	for i := 0; i < hedgingBudgetMaxTokens; i++ {
+		assert.True(t, budget.withdraw())
+	}
+	assert.False(t, budget.withdraw())
+	budget.deposit()
+	assert.False(t, budget.withdraw())
+	budget.deposit()
+	assert.True(t, budget.withdraw())
+}
+
+func TestLatencyWindowPercentile(t *testing.T) {
+	w := &latencyWindow{}
+	_, ok := w.percentile(0.9)
+	assert.False(t, ok)
+	for i := 1; i <= 100; i++ {
+		w.observe(time.Duration(i) * time.Millisecond)
+	}
+	d, ok := w.percentile(0.9)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Millisecond, d)
+}
+
+func TestHedgingDelay(t *testing.T) {
+	h := newHedgingMethod(HedgingPolicy{Method: "/helloworld.Greeter/SayHello", DelayPercentile: 0.9}, elog.DefaultLogger)
+	// 样本不足时使用固定的等待时间
+	assert.Equal(t, hedgingFallbackDelay, h.delay())
+	h.policy.Delay = 30 * time.Millisecond
+	assert.Equal(t, 30*time.Millisecond, h.delay())
+	for i := 1; i <= 100; i++ {
+		h.latency.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 90*time.Millisecond, h.delay())
+
+	// 没有配置 Delay 和 DelayPercentile 时同样不会立即发送对冲请求
+	h = newHedgingMethod(HedgingPolicy{Method: "/helloworld.Greeter/SayHello"}, elog.DefaultLogger)
+	assert.Equal(t, hedgingFallbackDelay, h.delay())
+}
+
+// GreeterHedging 第一次请求很慢，后续请求立即返回
+type GreeterHedging struct {
+	calls int32
+	helloworld.UnimplementedGreeterServer
+}
+
+// SayHello ...
+func (g *GreeterHedging) SayHello(ctx context.Context, request *helloworld.HelloRequest) (*helloworld.HelloResponse, error) {
+	if atomic.AddInt32(&g.calls, 1) == 1 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	return &helloworld.HelloResponse{
+		Message: "Hello " + request.Name,
+	}, nil
+}
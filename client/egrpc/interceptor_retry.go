@@ -0,0 +1,78 @@
+package egrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/internal/retry"
+)
+
+// parseRetryCodes 解析需要重试的错误码，忽略无法识别的错误码
+func parseRetryCodes(names []string, logger *elog.Component) map[codes.Code]struct{} {
+	retryCodes := make(map[codes.Code]struct{}, len(names))
+	for _, name := range names {
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(`"` + name + `"`)); err != nil {
+			logger.Warn("unknown retry status code", elog.FieldValue(name))
+			continue
+		}
+		retryCodes[code] = struct{}{}
+	}
+	return retryCodes
+}
+
+// retryUnaryClientInterceptor 请求失败且错误码在 RetryStatusCodes 中时，按指数退避重试
+// 重试策略在每次请求时读取，配置变更后立即生效
+func (c *Container) retryUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		c.config.mu.RLock()
+		maxAttempts := c.config.RetryMaxAttempts
+		backoff := c.config.RetryBackoff
+		retryCodes := c.config.retryCodes
+		c.config.mu.RUnlock()
+		if maxAttempts <= 1 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		var err error
+		options := retry.Options{BackoffMultiplier: 2, BackoffMinDuration: backoff}
+		for attempt := 0; attempt < maxAttempts; attempt++ {
+			// 第一次重试等待 RetryBackoff，之后每次翻倍，与 ehttp 保持一致
+			if attempt > 0 && !sleepContext(ctx, retry.Delay(attempt-1, options)) {
+				break
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil {
+				return nil
+			}
+			if _, ok := retryCodes[status.Code(err)]; !ok {
+				return err
+			}
+		}
+		if err == nil {
+			// 首次请求前 context 已经结束
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return err
+	}
+}
+
+// sleepContext 等待 d 或者 context 结束，context 结束时返回 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
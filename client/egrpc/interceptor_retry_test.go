@@ -0,0 +1,85 @@
+package egrpc
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gotomicro/ego/internal/test/helloworld"
+)
+
+func TestRetryUnaryClientInterceptor(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	greeter := &GreeterRetry{failures: 2}
+	helloworld.RegisterGreeterServer(server, greeter)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	defer server.Stop()
+
+	container := DefaultContainer()
+	container.config.RetryMaxAttempts = 3
+	container.config.RetryBackoff = 10 * time.Millisecond
+	cmp := container.Build(
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+	)
+	cli := helloworld.NewGreeterClient(cmp)
+
+	res, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello ego", res.Message)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&greeter.calls))
+
+	// 不在 RetryStatusCodes 中的错误码不重试
+	atomic.StoreInt32(&greeter.calls, 0)
+	_, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "invalid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&greeter.calls))
+
+	// 超过最大请求次数后返回最后一次的错误
+	atomic.StoreInt32(&greeter.calls, -5)
+	_, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(-2), atomic.LoadInt32(&greeter.calls))
+
+	// 第一次重试等待 RetryBackoff，而不是 2*RetryBackoff
+	container.config.RetryMaxAttempts = 2
+	container.config.RetryBackoff = 200 * time.Millisecond
+	atomic.StoreInt32(&greeter.calls, 1)
+	start := time.Now()
+	_, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 300*time.Millisecond)
+}
+
+// GreeterRetry 前 failures 次请求返回 UNAVAILABLE
+type GreeterRetry struct {
+	failures int32
+	calls    int32
+	helloworld.UnimplementedGreeterServer
+}
+
+// SayHello ...
+func (g *GreeterRetry) SayHello(ctx context.Context, request *helloworld.HelloRequest) (*helloworld.HelloResponse, error) {
+	if request.Name == "invalid" {
+		atomic.AddInt32(&g.calls, 1)
+		return nil, status.Error(codes.InvalidArgument, "invalid name")
+	}
+	if atomic.AddInt32(&g.calls, 1) <= g.failures {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+	return &helloworld.HelloResponse{Message: "Hello " + request.Name}, nil
+}
@@ -0,0 +1,253 @@
+package egrpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/core/emetric"
+	"github.com/gotomicro/ego/core/etrace"
+	"github.com/gotomicro/ego/internal/ecode"
+)
+
+// monitoredClientStream 统计流式请求收发的消息，并在流结束时回调 onFinish
+// 流结束包括：RecvMsg 返回错误或 io.EOF、非服务端流收到响应、context 结束
+type monitoredClientStream struct {
+	grpc.ClientStream
+	desc      *grpc.StreamDesc
+	onMessage func(direction string, size int)
+	onFinish  func(err error)
+	once      sync.Once
+	stop      func() bool
+
+	recvMsgs  int64
+	sentMsgs  int64
+	recvBytes int64
+	sentBytes int64
+	// lastActive 最后一次收发消息的时间，单位ns
+	lastActive int64
+}
+
+func newMonitoredClientStream(ctx context.Context, s grpc.ClientStream, desc *grpc.StreamDesc, onMessage func(direction string, size int), onFinish func(err error)) *monitoredClientStream {
+	stream := &monitoredClientStream{
+		ClientStream: s,
+		desc:         desc,
+		onMessage:    onMessage,
+		onFinish:     onFinish,
+		lastActive:   time.Now().UnixNano(),
+	}
+	stream.stop = context.AfterFunc(ctx, func() {
+		stream.finish(ctx.Err())
+	})
+	return stream
+}
+
+// RecvMsg ...
+func (s *monitoredClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.observe(emetric.DirectionRecv, &s.recvMsgs, &s.recvBytes, m)
+		if !s.desc.ServerStreams {
+			s.finish(nil)
+		}
+		return nil
+	}
+	if err == io.EOF {
+		s.finish(nil)
+	} else {
+		s.finish(err)
+	}
+	return err
+}
+
+// SendMsg ...
+func (s *monitoredClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.observe(emetric.DirectionSent, &s.sentMsgs, &s.sentBytes, m)
+	}
+	return err
+}
+
+func (s *monitoredClientStream) observe(direction string, msgs, bytes *int64, m interface{}) {
+	size := 0
+	if msg, ok := m.(proto.Message); ok {
+		size = proto.Size(msg)
+	}
+	atomic.AddInt64(msgs, 1)
+	atomic.AddInt64(bytes, int64(size))
+	atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+	if s.onMessage != nil {
+		s.onMessage(direction, size)
+	}
+}
+
+func (s *monitoredClientStream) finish(err error) {
+	s.once.Do(func() {
+		s.stop()
+		s.onFinish(err)
+	})
+}
+
+// idle 距离最后一次收发消息的时间
+func (s *monitoredClientStream) idle() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&s.lastActive)))
+}
+
+// loggerStreamClientInterceptor 流结束时记录access日志，包含收发的消息数量和字节数
+func (c *Container) loggerStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		beg := time.Now()
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			c.logStream(ctx, method, cc.Target(), time.Since(beg), err, nil)
+			return s, err
+		}
+		var stream *monitoredClientStream
+		stream = newMonitoredClientStream(ctx, s, desc, nil, func(err error) {
+			c.logStream(ctx, method, cc.Target(), time.Since(beg), err, stream)
+		})
+		return stream, nil
+	}
+}
+
+func (c *Container) logStream(ctx context.Context, method, target string, cost time.Duration, err error, stream *monitoredClientStream) {
+	var event = "normal"
+	var isSlowLog = false
+	c.config.mu.RLock()
+	enableAccess := c.config.EnableAccessInterceptor
+	slowLogThreshold := c.config.SlowLogThreshold
+	c.config.mu.RUnlock()
+	if slowLogThreshold > time.Duration(0) && cost > slowLogThreshold {
+		isSlowLog = true
+		event = "slow"
+	}
+	// 流式请求通常是长连接，只有开启了AccessInterceptor或发生错误时记日志
+	if !enableAccess && err == nil {
+		return
+	}
+	spbStatus := ecode.Convert(err)
+	httpStatusCode := ecode.GrpcToHTTPStatusCode(spbStatus.Code())
+	fields := make([]elog.Field, 0, 20)
+	fields = append(fields,
+		elog.FieldKey("stream"),
+		elog.FieldEvent(event),
+		elog.FieldCode(int32(spbStatus.Code())),
+		elog.FieldUniformCode(int32(httpStatusCode)),
+		elog.FieldDescription(spbStatus.Message()),
+		elog.FieldMethod(method),
+		elog.FieldCost(cost),
+		elog.FieldName(target),
+	)
+	if stream != nil {
+		fields = append(fields,
+			elog.Int64("recvMsgs", atomic.LoadInt64(&stream.recvMsgs)),
+			elog.Int64("sentMsgs", atomic.LoadInt64(&stream.sentMsgs)),
+			elog.Int64("recvBytes", atomic.LoadInt64(&stream.recvBytes)),
+			elog.Int64("sentBytes", atomic.LoadInt64(&stream.sentBytes)),
+		)
+	}
+	// 开启了链路，那么就记录链路id
+	if etrace.IsGlobalTracerRegistered() {
+		fields = append(fields, elog.FieldTid(etrace.ExtractTraceID(ctx)))
+	}
+	if err != nil {
+		fields = append(fields, elog.FieldErr(err))
+		if httpStatusCode >= http.StatusInternalServerError {
+			c.logger.Error("access", fields...)
+		} else {
+			c.logger.Warn("access", fields...)
+		}
+		return
+	}
+	if isSlowLog {
+		c.logger.Warn("access", fields...)
+		return
+	}
+	c.logger.Info("access", fields...)
+}
+
+// metricStreamClientInterceptor 流式请求的时长、状态和每条消息的监控
+func (c *Container) metricStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		beg := time.Now()
+		target := cc.Target()
+		emetric.ClientStartedCounter.Inc(emetric.TypeGRPCStream, c.name, method, target)
+		onFinish := func(err error) {
+			emetric.ClientHandleHistogram.ObserveWithExemplar(time.Since(beg).Seconds(), prometheus.Labels{
+				"tid": etrace.ExtractTraceID(ctx),
+			}, emetric.TypeGRPCStream, c.name, method, target)
+			emetric.ClientHandleCounter.Inc(emetric.TypeGRPCStream, c.name, method, target, ecode.Convert(err).Code().String())
+		}
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			onFinish(err)
+			return s, err
+		}
+		return newMonitoredClientStream(ctx, s, desc, func(direction string, size int) {
+			emetric.ClientStreamMsgCounter.Inc(c.name, method, target, direction)
+			emetric.ClientStreamMsgBytesCounter.Add(float64(size), c.name, method, target, direction)
+		}, onFinish), nil
+	}
+}
+
+// timeoutStreamClientInterceptor 流式请求的空闲超时和最大时长，超时后取消请求
+func (c *Container) timeoutStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		var cancel context.CancelFunc
+		if c.config.StreamMaxDuration > 0 {
+			ctx, cancel = context.WithTimeoutCause(ctx, c.config.StreamMaxDuration, fmt.Errorf("grpc client stream max duration"))
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return s, err
+		}
+		done := make(chan struct{})
+		stream := newMonitoredClientStream(ctx, s, desc, nil, func(err error) {
+			close(done)
+			cancel()
+		})
+		if c.config.StreamIdleTimeout > 0 {
+			go func() {
+				ticker := time.NewTicker(streamIdleCheckInterval(c.config.StreamIdleTimeout))
+				defer ticker.Stop()
+				for {
+					select {
+					case <-done:
+						return
+					case <-ticker.C:
+						if stream.idle() > c.config.StreamIdleTimeout {
+							cancel()
+							return
+						}
+					}
+				}
+			}()
+		}
+		return stream, nil
+	}
+}
+
+// streamIdleCheckInterval 空闲检查的间隔，最大1s
+func streamIdleCheckInterval(idleTimeout time.Duration) time.Duration {
+	interval := idleTimeout / 4
+	if interval > time.Second {
+		interval = time.Second
+	}
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	return interval
+}
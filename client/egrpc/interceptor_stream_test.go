@@ -0,0 +1,92 @@
+package egrpc
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gotomicro/ego/internal/test/helloworld"
+)
+
+func TestStreamInterceptors(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	helloworld.RegisterGreeterServer(server, &GreeterStream{})
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	ts := httptest.NewServer(promhttp.Handler())
+	defer ts.Close()
+
+	container := DefaultContainer()
+	container.config.StreamIdleTimeout = 200 * time.Millisecond
+	cmp := container.Build(
+		WithName("stream"),
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+		WithEnableAccessInterceptor(true),
+	)
+	cli := helloworld.NewGreeterClient(cmp.ClientConn)
+
+	stream, err := cli.SayHelloUnary2Stream(context.Background(), &helloworld.HelloRequest{Name: "3"})
+	require.NoError(t, err)
+	count := 0
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		count++
+	}
+	assert.Equal(t, 3, count)
+
+	res, err := ts.Client().Get(ts.URL)
+	require.NoError(t, err)
+	text, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Contains(t, string(text), `ego_client_handle_seconds_count{method="/helloworld.Greeter/SayHelloUnary2Stream",name="stream",peer="bufnet",type="stream"} 1`)
+	assert.Contains(t, string(text), `ego_client_stream_msg_total{direction="recv",method="/helloworld.Greeter/SayHelloUnary2Stream",name="stream",peer="bufnet"} 3`)
+	assert.Contains(t, string(text), `ego_client_handle_total{code="OK",method="/helloworld.Greeter/SayHelloUnary2Stream",name="stream",peer="bufnet",type="stream"} 1`)
+
+	// 服务端一直不发送消息，触发空闲超时
+	begin := time.Now()
+	stream, err = cli.SayHelloUnary2Stream(context.Background(), &helloworld.HelloRequest{Name: "idle"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.True(t, time.Since(begin) < time.Second)
+}
+
+// GreeterStream 按照 Name 返回若干条消息，Name 为 idle 时一直不返回
+type GreeterStream struct {
+	helloworld.UnimplementedGreeterServer
+}
+
+// SayHelloUnary2Stream ...
+func (g GreeterStream) SayHelloUnary2Stream(request *helloworld.HelloRequest, stream helloworld.Greeter_SayHelloUnary2StreamServer) error {
+	if request.Name == "idle" {
+		<-stream.Context().Done()
+		return stream.Context().Err()
+	}
+	for i := 0; i < 3; i++ {
+		if err := stream.Send(&helloworld.HelloResponse{Message: "Hello"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
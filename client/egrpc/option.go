@@ -6,7 +6,10 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gotomicro/ego/core/elog"
 )
 
 // WithAddr setting grpc server address
@@ -102,3 +105,72 @@ func WithMaxRecvMsgSize(maxRecvMsgSize int) Option {
 		c.config.MaxCallRecvMsgSize = maxRecvMsgSize
 	}
 }
+
+// WithHedgingPolicy 设置对冲请求策略
+func WithHedgingPolicy(policies ...HedgingPolicy) Option {
+	return func(c *Container) {
+		c.config.HedgingPolicies = append(c.config.HedgingPolicies, policies...)
+	}
+}
+
+// WithCachePolicy 设置响应缓存策略
+func WithCachePolicy(policies ...CachePolicy) Option {
+	return func(c *Container) {
+		c.config.CachePolicies = append(c.config.CachePolicies, policies...)
+	}
+}
+
+// WithPoolSize 设置连接池大小和选择连接的方式，strategy 为空时使用 round_robin
+func WithPoolSize(size int, strategy string) Option {
+	return func(c *Container) {
+		c.config.PoolSize = size
+		if strategy != "" {
+			c.config.PoolStrategy = strategy
+		}
+	}
+}
+
+// WithRecord 将请求的方法、metadata、收发的消息和状态录制到文件，用于 WithReplay 回放
+// authorization、cookie 等凭证默认脱敏后写入，redactMetadata 为额外需要脱敏的 metadata key
+func WithRecord(file string, redactMetadata ...string) Option {
+	return func(c *Container) {
+		c.config.recordFile = file
+		c.config.recordRedactMetadata = redactMetadata
+	}
+}
+
+// WithReplay 启动 bufconn 服务端回放录制的请求，不需要启动下游服务即可测试
+func WithReplay(config ReplayConfig) Option {
+	return func(c *Container) {
+		listener, server, err := newReplayServer(config)
+		if err != nil {
+			c.logger.Panic("replay grpc interactions", elog.FieldErr(err), elog.String("file", config.File))
+			return
+		}
+		c.config.closers = append(c.config.closers, replayServerCloser{server})
+		c.config.Addr = "bufnet"
+		WithBufnetServerListener(listener)(c)
+	}
+}
+
+// WithTLS 开启 TLS，certFile、keyFile 为空时不使用客户端证书，caFiles 为空时使用系统CA
+func WithTLS(certFile, keyFile string, caFiles ...string) Option {
+	return func(c *Container) {
+		c.config.EnableTLS = true
+		c.config.TLSCertFile = certFile
+		c.config.TLSKeyFile = keyFile
+		c.config.TLSCAFiles = caFiles
+	}
+}
+
+// WithTLSServerName 覆盖校验服务端证书使用的域名
+func WithTLSServerName(serverName string) Option {
+	return func(c *Container) {
+		c.config.TLSServerName = serverName
+	}
+}
+
+// WithPerRPCCredentials 设置每次请求携带的凭证，例如 NewStaticTokenCredentials、NewOAuth2Credentials
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return WithDialOption(grpc.WithPerRPCCredentials(creds))
+}
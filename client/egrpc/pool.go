@@ -0,0 +1,137 @@
+package egrpc
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/gotomicro/ego/core/emetric"
+)
+
+const (
+	// PoolStrategyRoundRobin 轮询选择连接
+	PoolStrategyRoundRobin = "round_robin"
+	// PoolStrategyLeastBusy 选择正在进行的请求数最少的连接
+	PoolStrategyLeastBusy = "least_busy"
+)
+
+// refConn 记录连接上正在进行的请求数，切换连接后等待请求结束再关闭旧连接
+type refConn struct {
+	*grpc.ClientConn
+	index    string
+	inflight int64
+	closing  int32
+	gauge    prometheus.Gauge // 正在进行的请求数监控，未开启监控时为nil
+}
+
+// acquire 增加请求计数，连接正在关闭时返回false
+func (r *refConn) acquire() bool {
+	atomic.AddInt64(&r.inflight, 1)
+	if atomic.LoadInt32(&r.closing) == 1 {
+		atomic.AddInt64(&r.inflight, -1)
+		return false
+	}
+	if r.gauge != nil {
+		r.gauge.Inc()
+	}
+	return true
+}
+
+func (r *refConn) release() {
+	atomic.AddInt64(&r.inflight, -1)
+	if r.gauge != nil {
+		r.gauge.Dec()
+	}
+}
+
+// drain 等待请求结束或超时后关闭连接
+func (r *refConn) drain(timeout time.Duration) {
+	atomic.StoreInt32(&r.closing, 1)
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&r.inflight) > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	_ = r.ClientConn.Close()
+}
+
+// connPool 连接池，每个连接独立建立 HTTP/2 连接，避免单个连接的并发流数量限制成为瓶颈
+type connPool struct {
+	conns     []*refConn
+	leastBusy bool
+	next      uint64
+}
+
+// pick 按照策略选择一个连接
+func (p *connPool) pick() *refConn {
+	n := uint64(len(p.conns))
+	if n == 1 {
+		return p.conns[0]
+	}
+	start := atomic.AddUint64(&p.next, 1)
+	if !p.leastBusy {
+		return p.conns[start%n]
+	}
+	// 从轮询位置开始查找，请求数相同时不会总是选择第一个连接
+	best := p.conns[start%n]
+	for i := uint64(1); i < n; i++ {
+		conn := p.conns[(start+i)%n]
+		if atomic.LoadInt64(&conn.inflight) < atomic.LoadInt64(&best.inflight) {
+			best = conn
+		}
+	}
+	return best
+}
+
+// newConnPool 使用已经建立的连接创建连接池，开启监控时记录每个连接的请求数和状态
+func (c *Component) newConnPool(ccs []*grpc.ClientConn) *connPool {
+	pool := &connPool{
+		conns:     make([]*refConn, 0, len(ccs)),
+		leastBusy: c.config.PoolStrategy == PoolStrategyLeastBusy,
+	}
+	for i, cc := range ccs {
+		conn := &refConn{ClientConn: cc, index: strconv.Itoa(i)}
+		if c.config.EnableMetricInterceptor {
+			conn.gauge = emetric.ClientConnInflightGauge.WithLabelValues(c.name, cc.Target(), conn.index)
+			go c.watchConnState(conn)
+		}
+		pool.conns = append(pool.conns, conn)
+	}
+	return pool
+}
+
+// watchConnState 连接状态变化时更新监控，只保留当前状态的监控，连接关闭后删除监控
+func (c *Component) watchConnState(conn *refConn) {
+	target := conn.Target()
+	state := conn.GetState()
+	for {
+		emetric.ClientConnStateGauge.Set(1, c.name, target, conn.index, state.String())
+		changed := conn.WaitForStateChange(context.Background(), state)
+		emetric.ClientConnStateGauge.Delete(prometheus.Labels{"name": c.name, "peer": target, "conn": conn.index, "state": state.String()})
+		if !changed {
+			break
+		}
+		state = conn.GetState()
+		if state == connectivity.Shutdown {
+			break
+		}
+	}
+	emetric.ClientConnInflightGauge.Delete(prometheus.Labels{"name": c.name, "peer": target, "conn": conn.index})
+}
+
+// ConnStates 返回连接池中每个连接的状态
+func (c *Component) ConnStates() []connectivity.State {
+	pool := c.pool.Load()
+	if pool == nil {
+		return nil
+	}
+	states := make([]connectivity.State, 0, len(pool.conns))
+	for _, conn := range pool.conns {
+		states = append(states, conn.GetState())
+	}
+	return states
+}
@@ -0,0 +1,93 @@
+package egrpc
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gotomicro/ego/internal/test/helloworld"
+)
+
+func TestConnPool(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	helloworld.RegisterGreeterServer(server, &GreeterRetry{})
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	defer server.Stop()
+	ts := httptest.NewServer(promhttp.Handler())
+	defer ts.Close()
+
+	cmp := DefaultContainer().Build(
+		WithName("pool"),
+		WithPoolSize(3, PoolStrategyLeastBusy),
+		WithAddr("bufnet"),
+		WithBufnetServerListener(listener),
+	)
+	defer cmp.Close()
+	assert.Equal(t, []connectivity.State{connectivity.Ready, connectivity.Ready, connectivity.Ready}, cmp.ConnStates())
+
+	// Component 和 ClientConn 都使用连接池
+	for _, cc := range []grpc.ClientConnInterface{cmp, cmp.ClientConn} {
+		cli := helloworld.NewGreeterClient(cc)
+		for i := 0; i < 3; i++ {
+			_, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "ego"})
+			require.NoError(t, err)
+		}
+	}
+
+	scrape := func() string {
+		res, err := ts.Client().Get(ts.URL)
+		require.NoError(t, err)
+		text, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		return string(text)
+	}
+	text := scrape()
+	for _, index := range []string{"0", "1", "2"} {
+		assert.Contains(t, text, `ego_client_conn_inflight{conn="`+index+`",name="pool",peer="bufnet"} 0`)
+		assert.Contains(t, text, `ego_client_conn_state{conn="`+index+`",name="pool",peer="bufnet",state="READY"} 1`)
+	}
+	// 只保留当前状态的监控
+	assert.Equal(t, 3, strings.Count(text, `name="pool",peer="bufnet",state=`))
+
+	// 连接关闭后删除监控
+	require.NoError(t, cmp.Close())
+	assert.Eventually(t, func() bool {
+		text := scrape()
+		return !strings.Contains(text, `name="pool",peer="bufnet",state=`) && !strings.Contains(text, `ego_client_conn_inflight{conn="0",name="pool"`)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestConnPoolPick(t *testing.T) {
+	conns := []*refConn{{index: "0"}, {index: "1"}, {index: "2"}}
+	pool := &connPool{conns: conns}
+	picked := map[string]int{}
+	for i := 0; i < 6; i++ {
+		picked[pool.pick().index]++
+	}
+	assert.Equal(t, map[string]int{"0": 2, "1": 2, "2": 2}, picked)
+
+	pool = &connPool{conns: conns, leastBusy: true}
+	conns[0].inflight = 3
+	conns[1].inflight = 1
+	conns[2].inflight = 2
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "1", pool.pick().index)
+	}
+}
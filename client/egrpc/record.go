@@ -0,0 +1,219 @@
+package egrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/core/emetric"
+)
+
+// Interaction 一次录制的请求，包含请求的方法、metadata、按顺序收发的消息和最终状态
+type Interaction struct {
+	Method   string              `json:"method"`
+	Metadata map[string][]string `json:"metadata,omitempty"`
+	Messages []RecordedMessage   `json:"messages"`
+	Code     string              `json:"code"`
+	Message  string              `json:"message,omitempty"`
+}
+
+// RecordedMessage 录制的消息，Direction 为客户端视角的 sent 或 recv
+type RecordedMessage struct {
+	Direction string          `json:"direction"`
+	Type      string          `json:"type"`
+	JSON      json.RawMessage `json:"json,omitempty"` // 便于阅读，回放时不使用
+	Payload   []byte          `json:"payload"`
+}
+
+// newRecordedMessage 使用确定性的序列化方式记录消息，便于录制文件做 diff
+func newRecordedMessage(direction string, m interface{}) (RecordedMessage, error) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return RecordedMessage{Direction: direction}, nil
+	}
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return RecordedMessage{}, err
+	}
+	text, err := protojson.Marshal(msg)
+	if err != nil {
+		return RecordedMessage{}, err
+	}
+	return RecordedMessage{
+		Direction: direction,
+		Type:      string(msg.ProtoReflect().Descriptor().FullName()),
+		JSON:      text,
+		Payload:   payload,
+	}, nil
+}
+
+// LoadInteractions 读取录制文件
+func LoadInteractions(file string) ([]*Interaction, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []*Interaction
+	if err := json.Unmarshal(content, &interactions); err != nil {
+		return nil, err
+	}
+	return interactions, nil
+}
+
+// recordRedactedValue 敏感 metadata 在录制文件中的值
+const recordRedactedValue = "REDACTED"
+
+// defaultRecordRedactMetadata 默认脱敏的 metadata，避免凭证写入录制文件后被提交到代码仓库
+var defaultRecordRedactMetadata = []string{"authorization", "proxy-authorization", "cookie", "x-api-key"}
+
+// recorder 将请求录制到文件，每完成一次请求重写一次文件，测试异常退出时也能保留已经录制的请求
+type recorder struct {
+	file         string
+	logger       *elog.Component
+	redact       map[string]struct{}
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+func newRecorder(file string, redactMetadata []string, logger *elog.Component) *recorder {
+	redact := make(map[string]struct{}, len(defaultRecordRedactMetadata)+len(redactMetadata))
+	for _, key := range append(defaultRecordRedactMetadata, redactMetadata...) {
+		redact[strings.ToLower(key)] = struct{}{}
+	}
+	return &recorder{file: file, logger: logger, redact: redact}
+}
+
+func (r *recorder) add(interaction *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+	content, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err == nil {
+		err = os.WriteFile(r.file, content, 0644)
+	}
+	if err != nil {
+		r.logger.Error("record grpc interaction", elog.FieldErr(err), elog.String("file", r.file))
+	}
+}
+
+// newInteraction 使用 outgoing metadata 创建录制，需要脱敏的 metadata 只记录 key
+func (r *recorder) newInteraction(ctx context.Context, method string) *Interaction {
+	interaction := &Interaction{Method: method}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md) > 0 {
+		md = md.Copy()
+		for key := range md {
+			if _, ok := r.redact[key]; ok {
+				md[key] = []string{recordRedactedValue}
+			}
+		}
+		interaction.Metadata = md
+	}
+	return interaction
+}
+
+func (interaction *Interaction) finish(err error) {
+	st := status.Convert(err)
+	interaction.Code = st.Code().String()
+	interaction.Message = st.Message()
+}
+
+// recordUnaryClientInterceptor 录制 unary 请求
+func (c *Container) recordUnaryClientInterceptor(rec *recorder) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		interaction := rec.newInteraction(ctx, method)
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if msg, recordErr := newRecordedMessage(emetric.DirectionSent, req); recordErr == nil {
+			interaction.Messages = append(interaction.Messages, msg)
+		}
+		if err == nil {
+			if msg, recordErr := newRecordedMessage(emetric.DirectionRecv, reply); recordErr == nil {
+				interaction.Messages = append(interaction.Messages, msg)
+			}
+		}
+		interaction.finish(err)
+		rec.add(interaction)
+		return err
+	}
+}
+
+// recordStreamClientInterceptor 录制流式请求，按顺序记录收发的消息
+func (c *Container) recordStreamClientInterceptor(rec *recorder) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		interaction := rec.newInteraction(ctx, method)
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			interaction.finish(err)
+			rec.add(interaction)
+			return s, err
+		}
+		return &recordingClientStream{ClientStream: s, desc: desc, interaction: interaction, recorder: rec}, nil
+	}
+}
+
+// recordingClientStream 记录流式请求收发的消息，流结束时写入录制文件
+type recordingClientStream struct {
+	grpc.ClientStream
+	desc        *grpc.StreamDesc
+	interaction *Interaction
+	recorder    *recorder
+	mu          sync.Mutex
+	once        sync.Once
+}
+
+// SendMsg ...
+func (s *recordingClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.record(emetric.DirectionSent, m)
+	}
+	return err
+}
+
+// RecvMsg ...
+func (s *recordingClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.record(emetric.DirectionRecv, m)
+		if !s.desc.ServerStreams {
+			s.finish(nil)
+		}
+		return nil
+	}
+	if err == io.EOF {
+		s.finish(nil)
+	} else {
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *recordingClientStream) record(direction string, m interface{}) {
+	msg, err := newRecordedMessage(direction, m)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.interaction.Messages = append(s.interaction.Messages, msg)
+	s.mu.Unlock()
+}
+
+func (s *recordingClientStream) finish(err error) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		interaction := *s.interaction
+		interaction.Messages = append([]RecordedMessage(nil), s.interaction.Messages...)
+		s.mu.Unlock()
+		interaction.finish(err)
+		s.recorder.add(&interaction)
+	})
+}
@@ -0,0 +1,105 @@
+package egrpc
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/gotomicro/ego/core/emetric"
+	"github.com/gotomicro/ego/internal/test/helloworld"
+)
+
+// startRecordServer 启动录制使用的服务端
+func startRecordServer(t *testing.T) *bufconn.Listener {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	helloworld.RegisterGreeterServer(server, &GreeterRecord{})
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	t.Cleanup(server.Stop)
+	return listener
+}
+
+// GreeterRecord Name 为 invalid 时返回 InvalidArgument，流式请求按照 Name 返回消息
+type GreeterRecord struct {
+	helloworld.UnimplementedGreeterServer
+}
+
+// SayHello ...
+func (g GreeterRecord) SayHello(ctx context.Context, request *helloworld.HelloRequest) (*helloworld.HelloResponse, error) {
+	if request.Name == "invalid" {
+		return nil, status.Error(codes.InvalidArgument, "invalid name")
+	}
+	return &helloworld.HelloResponse{Message: "Hello " + request.Name}, nil
+}
+
+// SayHelloUnary2Stream ...
+func (g GreeterRecord) SayHelloUnary2Stream(request *helloworld.HelloRequest, stream helloworld.Greeter_SayHelloUnary2StreamServer) error {
+	for _, message := range []string{"Hello", request.Name} {
+		if err := stream.Send(&helloworld.HelloResponse{Message: message}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "greeter.json")
+	cmp := DefaultContainer().Build(
+		WithAddr("bufnet"),
+		WithBufnetServerListener(startRecordServer(t)),
+		WithRecord(file, "X-Session"),
+	)
+	defer cmp.Close()
+	cli := helloworld.NewGreeterClient(cmp)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret", "x-session", "secret", "x-tenant", "a")
+	_, err := cli.SayHello(ctx, &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	_, err = cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "invalid"})
+	require.Error(t, err)
+	stream, err := cli.SayHelloUnary2Stream(context.Background(), &helloworld.HelloRequest{Name: "3"})
+	require.NoError(t, err)
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	assert.Equal(t, io.EOF, err)
+
+	interactions, err := LoadInteractions(file)
+	require.NoError(t, err)
+	require.Len(t, interactions, 3)
+
+	assert.Equal(t, "/helloworld.Greeter/SayHello", interactions[0].Method)
+	assert.Equal(t, "OK", interactions[0].Code)
+	// 凭证脱敏后写入录制文件
+	assert.Equal(t, []string{recordRedactedValue}, interactions[0].Metadata["authorization"])
+	assert.Equal(t, []string{recordRedactedValue}, interactions[0].Metadata["x-session"])
+	assert.Equal(t, []string{"a"}, interactions[0].Metadata["x-tenant"])
+	require.Len(t, interactions[0].Messages, 2)
+	assert.Equal(t, emetric.DirectionSent, interactions[0].Messages[0].Direction)
+	assert.Equal(t, "helloworld.HelloRequest", interactions[0].Messages[0].Type)
+	assert.JSONEq(t, `{"name":"ego"}`, string(interactions[0].Messages[0].JSON))
+	assert.JSONEq(t, `{"message":"Hello ego"}`, string(interactions[0].Messages[1].JSON))
+
+	assert.Equal(t, "InvalidArgument", interactions[1].Code)
+	assert.Equal(t, "invalid name", interactions[1].Message)
+	assert.Len(t, interactions[1].Messages, 1)
+
+	assert.Equal(t, "/helloworld.Greeter/SayHelloUnary2Stream", interactions[2].Method)
+	assert.Len(t, interactions[2].Messages, 3)
+}
@@ -0,0 +1,229 @@
+package egrpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/gotomicro/ego/core/emetric"
+)
+
+const (
+	// ReplayModeStrict 方法、metadata、请求内容都需要与录制一致，每条录制只能使用一次，没有匹配的录制时返回错误
+	ReplayModeStrict = "strict"
+	// ReplayModeLenient 优先使用完全匹配的录制，没有时使用相同方法的第一条录制，录制可以重复使用，不校验后续的请求内容
+	ReplayModeLenient = "lenient"
+)
+
+// ReplayConfig 回放配置
+type ReplayConfig struct {
+	File          string                                   // 录制文件
+	Mode          string                                   // 回放模式，strict | lenient，默认strict，其他值返回错误
+	MatchMetadata []string                                 // 需要匹配的 metadata key，默认不匹配 metadata
+	Matcher       func(recorded, actual *Interaction) bool // 自定义匹配规则，actual 只包含方法、metadata 和第一条请求，设置后忽略 MatchMetadata 和请求内容匹配
+}
+
+// replayer 使用录制的请求模拟服务端
+type replayer struct {
+	config       ReplayConfig
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// newReplayServer 读取录制文件，启动 bufconn 服务端回放录制的请求
+func newReplayServer(config ReplayConfig) (*bufconn.Listener, *grpc.Server, error) {
+	switch config.Mode {
+	case "":
+		config.Mode = ReplayModeStrict
+	case ReplayModeStrict, ReplayModeLenient:
+	default:
+		return nil, nil, fmt.Errorf("unknown replay mode %q, must be %s or %s", config.Mode, ReplayModeStrict, ReplayModeLenient)
+	}
+	interactions, err := LoadInteractions(config.File)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &replayer{
+		config:       config,
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(r.handle))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	return listener, server, nil
+}
+
+// handle 找到匹配的录制，按录制的顺序收发消息，并返回录制的状态
+func (r *replayer) handle(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	actual := &Interaction{Method: method}
+	if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
+		actual.Metadata = md
+	}
+	// 录制的第一条消息是客户端发送的请求时，先接收请求再匹配
+	if r.startsWithRequest(method) {
+		payload, err := recvRaw(stream)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == nil {
+			actual.Messages = append(actual.Messages, RecordedMessage{Direction: emetric.DirectionSent, Payload: payload})
+		}
+	}
+	recorded := r.match(actual)
+	if recorded == nil {
+		return status.Errorf(codes.NotFound, "no recorded interaction matches %s", method)
+	}
+
+	messages := recorded.Messages
+	if len(actual.Messages) > 0 {
+		messages = messages[1:]
+	}
+	for _, msg := range messages {
+		if msg.Direction == emetric.DirectionRecv {
+			if err := sendRaw(stream, msg.Payload); err != nil {
+				return err
+			}
+			continue
+		}
+		payload, err := recvRaw(stream)
+		if err == io.EOF {
+			if r.config.Mode == ReplayModeStrict {
+				return status.Errorf(codes.FailedPrecondition, "%s: client closed stream before sending recorded request", method)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if r.config.Mode == ReplayModeStrict && !payloadEqual(msg.Type, msg.Payload, payload) {
+			return status.Errorf(codes.FailedPrecondition, "%s: request does not match recording", method)
+		}
+	}
+	code, ok := recordedCode(recorded.Code)
+	if !ok {
+		return status.Errorf(codes.Internal, "%s: unknown recorded code %s", method, recorded.Code)
+	}
+	if code == codes.OK {
+		return nil
+	}
+	return status.Error(code, recorded.Message)
+}
+
+// recordedCode 解析录制的状态码，录制时使用 codes.Code.String()
+func recordedCode(name string) (codes.Code, bool) {
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if code.String() == name {
+			return code, true
+		}
+	}
+	return codes.Unknown, false
+}
+
+// startsWithRequest 相同方法的录制是否以客户端请求开始
+func (r *replayer) startsWithRequest(method string) bool {
+	for _, interaction := range r.interactions {
+		if interaction.Method == method && len(interaction.Messages) > 0 {
+			return interaction.Messages[0].Direction == emetric.DirectionSent
+		}
+	}
+	return false
+}
+
+// match 返回匹配的录制，strict 模式下每条录制只能使用一次
+func (r *replayer) match(actual *Interaction) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var fallback *Interaction
+	for i, recorded := range r.interactions {
+		if recorded.Method != actual.Method {
+			continue
+		}
+		if r.config.Mode == ReplayModeStrict && r.used[i] {
+			continue
+		}
+		if fallback == nil {
+			fallback = recorded
+		}
+		if !r.matches(recorded, actual) {
+			continue
+		}
+		r.used[i] = true
+		return recorded
+	}
+	if r.config.Mode == ReplayModeLenient {
+		return fallback
+	}
+	return nil
+}
+
+func (r *replayer) matches(recorded, actual *Interaction) bool {
+	if r.config.Matcher != nil {
+		return r.config.Matcher(recorded, actual)
+	}
+	for _, key := range r.config.MatchMetadata {
+		if fmt.Sprint(recorded.Metadata[key]) != fmt.Sprint(actual.Metadata[key]) {
+			return false
+		}
+	}
+	if len(actual.Messages) > 0 && len(recorded.Messages) > 0 {
+		return payloadEqual(recorded.Messages[0].Type, recorded.Messages[0].Payload, actual.Messages[0].Payload)
+	}
+	return true
+}
+
+// payloadEqual 能找到消息类型时按 proto 语义比较，否则比较序列化后的内容
+func payloadEqual(typeName string, recorded, actual []byte) bool {
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(typeName))
+	if err != nil {
+		return bytes.Equal(recorded, actual)
+	}
+	recordedMsg := messageType.New().Interface()
+	actualMsg := messageType.New().Interface()
+	if proto.Unmarshal(recorded, recordedMsg) != nil || proto.Unmarshal(actual, actualMsg) != nil {
+		return bytes.Equal(recorded, actual)
+	}
+	return proto.Equal(recordedMsg, actualMsg)
+}
+
+// replayServerCloser 组件关闭时停止回放服务端
+type replayServerCloser struct {
+	*grpc.Server
+}
+
+// Close ...
+func (s replayServerCloser) Close() error {
+	s.Stop()
+	return nil
+}
+
+// recvRaw 不依赖消息类型接收消息，所有字段都保存在 unknown fields 中
+func recvRaw(stream grpc.ServerStream) ([]byte, error) {
+	msg := &emptypb.Empty{}
+	if err := stream.RecvMsg(msg); err != nil {
+		return nil, err
+	}
+	return msg.ProtoReflect().GetUnknown(), nil
+}
+
+// sendRaw 不依赖消息类型发送消息
+func sendRaw(stream grpc.ServerStream, payload []byte) error {
+	msg := &emptypb.Empty{}
+	msg.ProtoReflect().SetUnknown(protoreflect.RawFields(payload))
+	return stream.SendMsg(msg)
+}
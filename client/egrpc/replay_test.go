@@ -0,0 +1,92 @@
+package egrpc
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/gotomicro/ego/internal/test/helloworld"
+)
+
+func TestReplay(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "greeter.json")
+	recordCmp := DefaultContainer().Build(
+		WithAddr("bufnet"),
+		WithBufnetServerListener(startRecordServer(t)),
+		WithRecord(file),
+	)
+	recordCli := helloworld.NewGreeterClient(recordCmp)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "a")
+	_, err := recordCli.SayHello(ctx, &helloworld.HelloRequest{Name: "ego"})
+	require.NoError(t, err)
+	_, err = recordCli.SayHello(ctx, &helloworld.HelloRequest{Name: "invalid"})
+	require.Error(t, err)
+	stream, err := recordCli.SayHelloUnary2Stream(ctx, &helloworld.HelloRequest{Name: "stream"})
+	require.NoError(t, err)
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	require.Equal(t, io.EOF, err)
+	_ = recordCmp.Close()
+
+	t.Run("strict", func(t *testing.T) {
+		cmp := DefaultContainer().Build(WithReplay(ReplayConfig{File: file, MatchMetadata: []string{"x-tenant"}}))
+		defer cmp.Close()
+		cli := helloworld.NewGreeterClient(cmp)
+
+		res, err := cli.SayHello(ctx, &helloworld.HelloRequest{Name: "ego"})
+		require.NoError(t, err)
+		assert.Equal(t, "Hello ego", res.Message)
+		_, err = cli.SayHello(ctx, &helloworld.HelloRequest{Name: "invalid"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "invalid name", status.Convert(err).Message())
+
+		stream, err := cli.SayHelloUnary2Stream(ctx, &helloworld.HelloRequest{Name: "stream"})
+		require.NoError(t, err)
+		var messages []string
+		for {
+			res, err := stream.Recv()
+			if err != nil {
+				assert.Equal(t, io.EOF, err)
+				break
+			}
+			messages = append(messages, res.Message)
+		}
+		assert.Equal(t, []string{"Hello", "stream"}, messages)
+
+		// 每条录制只能使用一次
+		_, err = cli.SayHello(ctx, &helloworld.HelloRequest{Name: "ego"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("strict metadata mismatch", func(t *testing.T) {
+		cmp := DefaultContainer().Build(WithReplay(ReplayConfig{File: file, MatchMetadata: []string{"x-tenant"}}))
+		defer cmp.Close()
+		otherCtx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant", "b")
+		_, err := helloworld.NewGreeterClient(cmp).SayHello(otherCtx, &helloworld.HelloRequest{Name: "ego"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("lenient", func(t *testing.T) {
+		cmp := DefaultContainer().Build(WithReplay(ReplayConfig{File: file, Mode: ReplayModeLenient}))
+		defer cmp.Close()
+		cli := helloworld.NewGreeterClient(cmp)
+		for i := 0; i < 2; i++ {
+			res, err := cli.SayHello(context.Background(), &helloworld.HelloRequest{Name: "other"})
+			require.NoError(t, err)
+			assert.Equal(t, "Hello ego", res.Message)
+		}
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		_, _, err := newReplayServer(ReplayConfig{File: file, Mode: "loose"})
+		assert.EqualError(t, err, `unknown replay mode "loose", must be strict or lenient`)
+	})
+}
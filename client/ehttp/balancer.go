@@ -0,0 +1,194 @@
+package ehttp
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/gotomicro/ego/client/ehttp/resolver"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+const (
+	// BalancerRoundRobin 轮询
+	BalancerRoundRobin = "round_robin"
+	// BalancerWeightedRoundRobin 按节点权重平滑加权轮询
+	BalancerWeightedRoundRobin = "weighted_round_robin"
+	// BalancerLeastRequest 选择正在进行的请求数最少的节点
+	BalancerLeastRequest = "least_request"
+)
+
+type nodeKey struct{}
+
+// nodeStats 节点的请求统计
+type nodeStats struct {
+	inflight          int
+	consecutiveErrors int
+	ejectedUntil      time.Time
+	currentWeight     int
+}
+
+// balancer 从解析出的所有节点中为每个请求选择一个节点
+// 节点连续出现连接错误或5xx达到 EjectionConsecutiveErrors 次后被摘除 EjectionDuration，所有节点都被摘除时使用全部节点
+type balancer struct {
+	name             string
+	policy           string
+	ejectionErrors   int
+	ejectionDuration time.Duration
+	logger           *elog.Component
+
+	mu    sync.Mutex
+	next  int
+	stats map[string]*nodeStats
+}
+
+func newBalancer(name string, config *Config, logger *elog.Component) *balancer {
+	return &balancer{
+		name:             name,
+		policy:           config.BalancerName,
+		ejectionErrors:   config.EjectionConsecutiveErrors,
+		ejectionDuration: config.EjectionDuration,
+		logger:           logger,
+		stats:            make(map[string]*nodeStats),
+	}
+}
+
+func (b *balancer) nodeStats(addr string) *nodeStats {
+	stats, ok := b.stats[addr]
+	if !ok {
+		stats = &nodeStats{}
+		b.stats[addr] = stats
+	}
+	return stats
+}
+
+// pick 选择节点并增加节点的请求数，请求结束后需要调用 done
+func (b *balancer) pick(nodes []resolver.Node) resolver.Node {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// 节点变化后清理已经下线节点的统计
+	if len(b.stats) > 2*len(nodes) {
+		stats := make(map[string]*nodeStats, len(nodes))
+		for _, node := range nodes {
+			if s, ok := b.stats[node.Addr]; ok {
+				stats[node.Addr] = s
+			}
+		}
+		b.stats = stats
+	}
+
+	now := time.Now()
+	candidates := make([]resolver.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if b.nodeStats(node.Addr).ejectedUntil.Before(now) {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = nodes
+	}
+
+	b.next++
+	var picked resolver.Node
+	switch b.policy {
+	case BalancerWeightedRoundRobin:
+		total := 0
+		var best *nodeStats
+		for _, node := range candidates {
+			stats := b.nodeStats(node.Addr)
+			stats.currentWeight += node.Weight
+			total += node.Weight
+			if best == nil || stats.currentWeight > best.currentWeight {
+				best = stats
+				picked = node
+			}
+		}
+		best.currentWeight -= total
+	case BalancerLeastRequest:
+		// 从轮询位置开始查找，请求数相同时不会总是选择第一个节点
+		var best *nodeStats
+		for i := range candidates {
+			node := candidates[(b.next+i)%len(candidates)]
+			stats := b.nodeStats(node.Addr)
+			if best == nil || stats.inflight < best.inflight {
+				best = stats
+				picked = node
+			}
+		}
+	default:
+		picked = candidates[b.next%len(candidates)]
+	}
+	b.nodeStats(picked.Addr).inflight++
+	return picked
+}
+
+// done 请求结束，连接错误或5xx时增加节点的连续错误次数
+func (b *balancer) done(addr string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.nodeStats(addr)
+	stats.inflight--
+	if !failed {
+		stats.consecutiveErrors = 0
+		return
+	}
+	stats.consecutiveErrors++
+	if b.ejectionErrors > 0 && stats.consecutiveErrors >= b.ejectionErrors {
+		stats.consecutiveErrors = 0
+		stats.ejectedUntil = time.Now().Add(b.ejectionDuration)
+		b.logger.Warn("eject http node", elog.FieldName(b.name), elog.FieldAddr(addr), elog.FieldCost(b.ejectionDuration))
+	}
+}
+
+// resolverNodes 返回解析器的所有节点，没有实现 resolver.NodesResolver 的解析器返回空
+func resolverNodes(builder resolver.Resolver) []resolver.Node {
+	if r, ok := builder.(resolver.NodesResolver); ok {
+		return r.GetNodes()
+	}
+	return nil
+}
+
+// balancerInterceptor 解析出多个节点时，为每个请求选择节点
+// 请求地址替换为节点地址，设置了 Host header 时仍然使用设置的 Host
+func balancerInterceptor(name string, config *Config, logger *elog.Component, builder resolver.Resolver) (resty.RequestMiddleware, resty.ResponseMiddleware, resty.ErrorHook) {
+	b := newBalancer(name, config, logger)
+	beforeFn := func(cli *resty.Client, req *resty.Request) error {
+		nodes := resolverNodes(builder)
+		// 直连地址，或者请求使用了完整的URL，不需要选择节点
+		if len(nodes) == 0 || strings.HasPrefix(req.URL, "http://") || strings.HasPrefix(req.URL, "https://") {
+			return nil
+		}
+		node := b.pick(nodes)
+		req.URL = strings.TrimRight(node.Addr, "/") + "/" + strings.TrimLeft(req.URL, "/")
+		// 访问日志记录实际请求的节点
+		if u, ok := req.Context().Value(urlKey{}).(*url.URL); ok {
+			if nodeURL, err := url.Parse(node.Addr); err == nil {
+				u.Host = nodeURL.Host
+			}
+		}
+		req.SetContext(context.WithValue(req.Context(), nodeKey{}, node.Addr))
+		return nil
+	}
+	afterFn := func(cli *resty.Client, res *resty.Response) error {
+		if addr, ok := res.Request.Context().Value(nodeKey{}).(string); ok {
+			b.done(addr, res.StatusCode() >= 500)
+		}
+		return nil
+	}
+	errorFn := func(req *resty.Request, err error) {
+		addr, ok := req.Context().Value(nodeKey{}).(string)
+		if !ok {
+			return
+		}
+		if v, ok := err.(*resty.ResponseError); ok && v.Response != nil && v.Response.RawResponse != nil {
+			b.done(addr, v.Response.StatusCode() >= 500)
+			return
+		}
+		b.done(addr, true)
+	}
+	return beforeFn, afterFn, errorFn
+}
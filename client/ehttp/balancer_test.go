@@ -0,0 +1,135 @@
+package ehttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gotomicro/ego/client/ehttp/resolver"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+type nodesResolver struct {
+	nodes []resolver.Node
+}
+
+func (r *nodesResolver) GetAddr() string {
+	return r.nodes[0].Addr
+}
+
+func (r *nodesResolver) GetNodes() []resolver.Node {
+	return r.nodes
+}
+
+func newTestBalancer(policy string) *balancer {
+	config := DefaultConfig()
+	config.BalancerName = policy
+	config.EjectionConsecutiveErrors = 2
+	config.EjectionDuration = time.Minute
+	return newBalancer("test", config, elog.DefaultLogger)
+}
+
+func TestBalancer_RoundRobin(t *testing.T) {
+	b := newTestBalancer(BalancerRoundRobin)
+	nodes := []resolver.Node{{Addr: "http://a", Weight: 100}, {Addr: "http://b", Weight: 100}}
+	counts := map[string]int{}
+	for i := 0; i < 10; i++ {
+		node := b.pick(nodes)
+		counts[node.Addr]++
+		b.done(node.Addr, false)
+	}
+	assert.Equal(t, map[string]int{"http://a": 5, "http://b": 5}, counts)
+}
+
+func TestBalancer_WeightedRoundRobin(t *testing.T) {
+	b := newTestBalancer(BalancerWeightedRoundRobin)
+	nodes := []resolver.Node{{Addr: "http://a", Weight: 300}, {Addr: "http://b", Weight: 100}}
+	var picked []string
+	for i := 0; i < 4; i++ {
+		node := b.pick(nodes)
+		picked = append(picked, node.Addr)
+		b.done(node.Addr, false)
+	}
+	assert.Equal(t, []string{"http://a", "http://a", "http://b", "http://a"}, picked)
+}
+
+func TestBalancer_LeastRequest(t *testing.T) {
+	b := newTestBalancer(BalancerLeastRequest)
+	nodes := []resolver.Node{{Addr: "http://a", Weight: 100}, {Addr: "http://b", Weight: 100}}
+	first := b.pick(nodes)
+	// 第一个请求没有结束，后续请求都选择另一个节点
+	for i := 0; i < 3; i++ {
+		node := b.pick(nodes)
+		assert.NotEqual(t, first.Addr, node.Addr)
+		b.done(node.Addr, false)
+	}
+}
+
+func TestBalancer_Ejection(t *testing.T) {
+	b := newTestBalancer(BalancerRoundRobin)
+	nodes := []resolver.Node{{Addr: "http://a", Weight: 100}, {Addr: "http://b", Weight: 100}}
+	for i := 0; i < 2; i++ {
+		b.pick(nodes)
+		b.done("http://a", true)
+	}
+	for i := 0; i < 4; i++ {
+		node := b.pick(nodes)
+		assert.Equal(t, "http://b", node.Addr)
+		b.done(node.Addr, false)
+	}
+
+	// 所有节点都被摘除时使用全部节点
+	for i := 0; i < 2; i++ {
+		b.pick(nodes)
+		b.done("http://b", true)
+	}
+	counts := map[string]int{}
+	for i := 0; i < 4; i++ {
+		node := b.pick(nodes)
+		counts[node.Addr]++
+		b.done(node.Addr, false)
+	}
+	assert.Equal(t, map[string]int{"http://a": 2, "http://b": 2}, counts)
+}
+
+func TestBalancerInterceptor(t *testing.T) {
+	var hosts []string
+	handler := func(code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			hosts = append(hosts, r.Host)
+			w.WriteHeader(code)
+		}
+	}
+	good := httptest.NewServer(handler(http.StatusOK))
+	defer good.Close()
+	bad := httptest.NewServer(handler(http.StatusInternalServerError))
+	defer bad.Close()
+
+	config := DefaultConfig()
+	config.EjectionConsecutiveErrors = 1
+	builder := &nodesResolver{nodes: []resolver.Node{{Addr: good.URL, Weight: 100}, {Addr: bad.URL, Weight: 100}}}
+	onBefore, onAfter, onErr := balancerInterceptor("test", config, elog.DefaultLogger, builder)
+	cli := resty.New().OnBeforeRequest(onBefore).OnAfterResponse(onAfter)
+	cli.OnError(onErr)
+
+	codes := map[int]int{}
+	for i := 0; i < 6; i++ {
+		res, err := cli.R().SetContext(context.Background()).Get("/hello")
+		assert.NoError(t, err)
+		codes[res.StatusCode()]++
+	}
+	// 返回5xx的节点被摘除后，请求都发送到正常的节点
+	assert.Equal(t, 1, codes[http.StatusInternalServerError])
+	assert.Equal(t, 5, codes[http.StatusOK])
+
+	// 设置了 Host header 时使用设置的 Host
+	hosts = nil
+	_, err := cli.R().SetHeader("Host", "example.com").Get("/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"example.com"}, hosts)
+}
@@ -0,0 +1,420 @@
+package ehttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gotomicro/ego/core/emetric"
+)
+
+const (
+	// HTTPCacheStoreMemory 内存 LRU 缓存
+	HTTPCacheStoreMemory = "memory"
+	// HTTPCacheStoreDisk 磁盘缓存
+	HTTPCacheStoreDisk = "disk"
+
+	// maxCacheBodySize 超过该大小的响应不缓存
+	maxCacheBodySize = 8 << 20
+)
+
+// cacheableStatusCodes 默认可以缓存的状态码，https://www.rfc-editor.org/rfc/rfc9110#section-15.1
+var cacheableStatusCodes = map[int]struct{}{
+	http.StatusOK:                   {},
+	http.StatusNonAuthoritativeInfo: {},
+	http.StatusNoContent:            {},
+	http.StatusMultipleChoices:      {},
+	http.StatusMovedPermanently:     {},
+	http.StatusPermanentRedirect:    {},
+	http.StatusNotFound:             {},
+	http.StatusMethodNotAllowed:     {},
+	http.StatusGone:                 {},
+	http.StatusRequestURITooLong:    {},
+	http.StatusNotImplemented:       {},
+}
+
+// cacheEntry 缓存的响应
+type cacheEntry struct {
+	StatusCode   int               `json:"statusCode"`
+	Header       http.Header       `json:"header"`
+	Body         []byte            `json:"body"`
+	RequestTime  time.Time         `json:"requestTime"`
+	ResponseTime time.Time         `json:"responseTime"`
+	Vary         map[string]string `json:"vary,omitempty"` // 响应 Vary 指定的请求 header 的值
+}
+
+// cacheTransport 按 RFC 9111 实现的客户端缓存，只缓存 GET 请求
+// 新鲜的缓存直接返回；过期或要求重新校验的缓存使用 ETag、Last-Modified 发送条件请求，304时返回缓存
+// 同一个 URL 只保存一个 Vary 版本；非安全方法请求成功后删除对应 URL 的缓存
+// 一个客户端通常为多个用户发送请求，按共享缓存处理带 Authorization 的请求，https://www.rfc-editor.org/rfc/rfc9111#section-3.5
+type cacheTransport struct {
+	name  string
+	next  http.RoundTripper
+	store CacheStore
+}
+
+func newCacheTransport(name string, config *Config, next http.RoundTripper) (*cacheTransport, error) {
+	store := config.cacheStore
+	if store == nil {
+		switch config.HTTPCacheStore {
+		case HTTPCacheStoreDisk:
+			var err error
+			if store, err = NewDiskCacheStore(config.HTTPCacheDir); err != nil {
+				return nil, err
+			}
+		case HTTPCacheStoreMemory, "":
+			store = NewMemoryCacheStore(config.HTTPCacheSize)
+		default:
+			return nil, fmt.Errorf("unknown http cache store %q", config.HTTPCacheStore)
+		}
+	}
+	return &cacheTransport{name: name, next: next, store: store}, nil
+}
+
+// RoundTrip ...
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	if req.Method != http.MethodGet {
+		res, err := t.next.RoundTrip(req)
+		if err == nil && !isSafeMethod(req.Method) && res.StatusCode < http.StatusBadRequest {
+			t.store.Delete(key)
+		}
+		return res, err
+	}
+	reqCC := parseCacheControl(req.Header)
+	// 调用方自己发送的条件请求，不使用缓存
+	if _, ok := reqCC["no-store"]; ok || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	method := req.Method + "." + req.URL.Path
+	entry := t.load(key, req)
+	if entry != nil && entry.fresh(reqCC, time.Now()) {
+		emetric.CacheHandleCounter.Inc(emetric.TypeHTTP, t.name, method, "hit")
+		return entry.response(req, time.Now()), nil
+	}
+	if _, ok := reqCC["only-if-cached"]; ok {
+		emetric.CacheHandleCounter.Inc(emetric.TypeHTTP, t.name, method, "miss")
+		return &http.Response{
+			Status:     "504 " + http.StatusText(http.StatusGatewayTimeout),
+			StatusCode: http.StatusGatewayTimeout,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	outReq := req
+	if entry != nil {
+		etag, lastModified := entry.Header.Get("ETag"), entry.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			outReq = req.Clone(req.Context())
+			if etag != "" {
+				outReq.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				outReq.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+	requestTime := time.Now()
+	res, err := t.next.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now()
+
+	if entry != nil && outReq != req && res.StatusCode == http.StatusNotModified {
+		emetric.CacheHandleCounter.Inc(emetric.TypeHTTP, t.name, method, "revalidated")
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		// 使用304响应的 header 更新缓存，https://www.rfc-editor.org/rfc/rfc9111#section-3.2
+		for name, values := range res.Header {
+			if name != "Content-Length" {
+				entry.Header[name] = values
+			}
+		}
+		entry.RequestTime = requestTime
+		entry.ResponseTime = responseTime
+		t.save(key, entry)
+		return entry.response(req, time.Now()), nil
+	}
+
+	emetric.CacheHandleCounter.Inc(emetric.TypeHTTP, t.name, method, "miss")
+	vary, ok := storable(req, reqCC, res)
+	if !ok {
+		if entry != nil {
+			t.store.Delete(key)
+		}
+		return res, nil
+	}
+	newEntry := &cacheEntry{
+		StatusCode:   res.StatusCode,
+		Header:       res.Header.Clone(),
+		RequestTime:  requestTime,
+		ResponseTime: responseTime,
+		Vary:         make(map[string]string, len(vary)),
+	}
+	for _, name := range vary {
+		newEntry.Vary[name] = req.Header.Get(name)
+	}
+	// 读完响应后再写入缓存，不影响流式读取
+	res.Body = &cachingBody{ReadCloser: res.Body, onEOF: func(body []byte) {
+		newEntry.Body = body
+		t.save(key, newEntry)
+	}}
+	return res, nil
+}
+
+// load 读取缓存，Vary 指定的请求 header 不一致时视为没有缓存
+func (t *cacheTransport) load(key string, req *http.Request) *cacheEntry {
+	value, ok := t.store.Get(key)
+	if !ok {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil
+	}
+	for name, value := range entry.Vary {
+		if req.Header.Get(name) != value {
+			return nil
+		}
+	}
+	return &entry
+}
+
+func (t *cacheTransport) save(key string, entry *cacheEntry) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	t.store.Set(key, value)
+}
+
+// storable 响应是否可以缓存，返回响应 Vary 指定的请求 header
+// 按共享缓存处理，private 的响应不缓存；带 Authorization 的请求，只有响应明确允许共享缓存（public、s-maxage）时才缓存
+func storable(req *http.Request, reqCC cacheControl, res *http.Response) ([]string, bool) {
+	if _, ok := cacheableStatusCodes[res.StatusCode]; !ok {
+		return nil, false
+	}
+	if _, ok := reqCC["no-store"]; ok {
+		return nil, false
+	}
+	resCC := parseCacheControl(res.Header)
+	if _, ok := resCC["no-store"]; ok {
+		return nil, false
+	}
+	if _, ok := resCC["private"]; ok {
+		return nil, false
+	}
+	if req.Header.Get("Authorization") != "" && !resCC.has("public", "s-maxage") {
+		return nil, false
+	}
+	// 没有过期时间也没有校验信息的响应缓存后无法使用
+	hasMaxAge := resCC.has("s-maxage", "max-age")
+	if !hasMaxAge && res.Header.Get("Expires") == "" && res.Header.Get("ETag") == "" && res.Header.Get("Last-Modified") == "" {
+		return nil, false
+	}
+	var vary []string
+	for _, value := range res.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return vary, true
+}
+
+// fresh 缓存是否可以不经校验直接使用
+func (e *cacheEntry) fresh(reqCC cacheControl, now time.Time) bool {
+	resCC := parseCacheControl(e.Header)
+	if _, ok := resCC["no-cache"]; ok {
+		return false
+	}
+	if _, ok := reqCC["no-cache"]; ok {
+		return false
+	}
+	age := e.age(now)
+	if maxAge, ok := reqCC.seconds("max-age"); ok && age > maxAge {
+		return false
+	}
+	lifetime := e.freshnessLifetime(resCC)
+	if minFresh, ok := reqCC.seconds("min-fresh"); ok {
+		lifetime -= minFresh
+	}
+	if age < lifetime {
+		return true
+	}
+	// 客户端可以接受过期的缓存，must-revalidate 时不可以
+	if _, ok := resCC["must-revalidate"]; ok {
+		return false
+	}
+	if maxStale, ok := reqCC["max-stale"]; ok {
+		if maxStale == "" {
+			return true
+		}
+		if stale, ok := reqCC.seconds("max-stale"); ok && age-lifetime < stale {
+			return true
+		}
+	}
+	return false
+}
+
+// freshnessLifetime https://www.rfc-editor.org/rfc/rfc9111#section-4.2.1
+// 按共享缓存处理，s-maxage 优先于 max-age
+func (e *cacheEntry) freshnessLifetime(resCC cacheControl) time.Duration {
+	if sMaxAge, ok := resCC.seconds("s-maxage"); ok {
+		return sMaxAge
+	}
+	if maxAge, ok := resCC.seconds("max-age"); ok {
+		return maxAge
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(e.date())
+	}
+	// 启发式过期时间，Last-Modified 到响应时间的10%
+	if lastModified := e.Header.Get("Last-Modified"); lastModified != "" {
+		t, err := http.ParseTime(lastModified)
+		if err != nil {
+			return 0
+		}
+		return e.date().Sub(t) / 10
+	}
+	return 0
+}
+
+// age https://www.rfc-editor.org/rfc/rfc9111#section-4.2.3
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := e.ResponseTime.Sub(e.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	var ageValue time.Duration
+	if seconds, err := strconv.Atoi(e.Header.Get("Age")); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+	correctedAge := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	initialAge := apparentAge
+	if correctedAge > initialAge {
+		initialAge = correctedAge
+	}
+	return initialAge + now.Sub(e.ResponseTime)
+}
+
+func (e *cacheEntry) date() time.Time {
+	if t, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return t
+	}
+	return e.ResponseTime
+}
+
+// response 使用缓存构造响应，并设置 Age
+func (e *cacheEntry) response(req *http.Request, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(e.age(now).Seconds())))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheControl 解析后的 Cache-Control，没有值的指令 value 为空
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(directive, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	value, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// has 是否包含任意一个指令
+func (cc cacheControl) has(names ...string) bool {
+	for _, name := range names {
+		if _, ok := cc[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// cachingBody 读到 EOF 时将完整的响应内容交给 onEOF，超过 maxCacheBodySize 或没有读完时不缓存
+type cachingBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	onEOF    func(body []byte)
+	finished bool
+}
+
+// Read ...
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.finished {
+		return n, err
+	}
+	b.buf.Write(p[:n])
+	if b.buf.Len() > maxCacheBodySize {
+		b.finished = true
+		b.buf = bytes.Buffer{}
+		return n, err
+	}
+	if err == io.EOF {
+		b.finished = true
+		b.onEOF(b.buf.Bytes())
+	}
+	return n, err
+}
@@ -0,0 +1,131 @@
+package ehttp
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheStore HTTP缓存的存储，value 为序列化后的响应
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// memoryCacheStore 内存 LRU 缓存
+type memoryCacheStore struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCacheStore 创建内存 LRU 缓存，超过 size 条时淘汰最久未使用的缓存
+func NewMemoryCacheStore(size int) CacheStore {
+	if size <= 0 {
+		size = 1000
+	}
+	return &memoryCacheStore{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get ...
+func (m *memoryCacheStore) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	m.ll.MoveToFront(elem)
+	return elem.Value.(*memoryCacheEntry).value, true
+}
+
+// Set ...
+func (m *memoryCacheStore) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.items[key]; ok {
+		elem.Value.(*memoryCacheEntry).value = value
+		m.ll.MoveToFront(elem)
+		return
+	}
+	m.items[key] = m.ll.PushFront(&memoryCacheEntry{key: key, value: value})
+	for m.ll.Len() > m.size {
+		oldest := m.ll.Back()
+		m.ll.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete ...
+func (m *memoryCacheStore) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.items[key]; ok {
+		m.ll.Remove(elem)
+		delete(m.items, key)
+	}
+}
+
+// diskCacheStore 磁盘缓存，每个缓存一个文件，不会自动淘汰
+type diskCacheStore struct {
+	dir string
+}
+
+// NewDiskCacheStore 创建磁盘缓存，目录不存在时自动创建
+func NewDiskCacheStore(dir string) (CacheStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &diskCacheStore{dir: dir}, nil
+}
+
+func (d *diskCacheStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get ...
+func (d *diskCacheStore) Get(key string) ([]byte, bool) {
+	value, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// Set 先写临时文件再重命名，避免并发读到写了一半的文件
+func (d *diskCacheStore) Set(key string, value []byte) {
+	file, err := os.CreateTemp(d.dir, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(value)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), d.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+}
+
+// Delete ...
+func (d *diskCacheStore) Delete(key string) {
+	_ = os.Remove(d.path(key))
+}
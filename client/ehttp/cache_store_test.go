@@ -0,0 +1,47 @@
+package ehttp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	store.Set("a", []byte("1"))
+	store.Set("b", []byte("2"))
+	// 访问 a 后 b 是最久未使用的缓存
+	_, ok := store.Get("a")
+	assert.True(t, ok)
+	store.Set("c", []byte("3"))
+	_, ok = store.Get("b")
+	assert.False(t, ok)
+	value, ok := store.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	store.Delete("a")
+	_, ok = store.Get("a")
+	assert.False(t, ok)
+}
+
+func TestDiskCacheStore(t *testing.T) {
+	store, err := NewDiskCacheStore(t.TempDir())
+	require.NoError(t, err)
+	_, ok := store.Get("http://example.com/a")
+	assert.False(t, ok)
+
+	store.Set("http://example.com/a", []byte("1"))
+	value, ok := store.Get("http://example.com/a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	store.Set("http://example.com/a", []byte("2"))
+	value, _ = store.Get("http://example.com/a")
+	assert.Equal(t, []byte("2"), value)
+
+	store.Delete("http://example.com/a")
+	_, ok = store.Get("http://example.com/a")
+	assert.False(t, ok)
+}
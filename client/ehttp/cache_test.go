@@ -0,0 +1,228 @@
+package ehttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotomicro/ego/core/elog"
+)
+
+func newCacheTestComponent(addr string, store CacheStore) *Component {
+	config := DefaultConfig()
+	config.Addr = addr
+	config.EnableHTTPCache = true
+	config.cacheStore = store
+	return newComponent("test", config, elog.DefaultLogger)
+}
+
+func TestCacheTransport_MaxAge(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = fmt.Fprintf(w, "rate-%d", n)
+	}))
+	defer server.Close()
+
+	for _, store := range []CacheStore{NewMemoryCacheStore(10), mustDiskCacheStore(t)} {
+		atomic.StoreInt32(&calls, 0)
+		c := newCacheTestComponent(server.URL, store)
+		for i := 0; i < 3; i++ {
+			res, err := c.R().Get("/rates")
+			require.NoError(t, err)
+			assert.Equal(t, "rate-1", res.String())
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		// 请求要求重新校验时不使用缓存
+		res, err := c.R().SetHeader("Cache-Control", "no-cache").Get("/rates")
+		require.NoError(t, err)
+		assert.Equal(t, "rate-2", res.String())
+
+		// 非安全方法请求成功后删除缓存
+		_, err = c.R().Post("/rates")
+		require.NoError(t, err)
+		res, err = c.R().Get("/rates")
+		require.NoError(t, err)
+		assert.Equal(t, "rate-4", res.String())
+	}
+}
+
+func mustDiskCacheStore(t *testing.T) CacheStore {
+	store, err := NewDiskCacheStore(t.TempDir())
+	require.NoError(t, err)
+	return store
+}
+
+func TestCacheTransport_Revalidate(t *testing.T) {
+	var calls, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("catalog"))
+	}))
+	defer server.Close()
+
+	c := newCacheTestComponent(server.URL, NewMemoryCacheStore(10))
+	for i := 0; i < 3; i++ {
+		res, err := c.R().Get("/catalog")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "catalog", res.String())
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&notModified))
+}
+
+func TestCacheTransport_Vary(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	c := newCacheTestComponent(server.URL, NewMemoryCacheStore(10))
+	res, err := c.R().SetHeader("Accept-Language", "en").Get("/")
+	require.NoError(t, err)
+	assert.Equal(t, "en", res.String())
+	res, err = c.R().SetHeader("Accept-Language", "en").Get("/")
+	require.NoError(t, err)
+	assert.Equal(t, "en", res.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	res, err = c.R().SetHeader("Accept-Language", "zh").Get("/")
+	require.NoError(t, err)
+	assert.Equal(t, "zh", res.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheTransport_NoStore(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "no-store, max-age=60")
+	}))
+	defer server.Close()
+
+	c := newCacheTestComponent(server.URL, NewMemoryCacheStore(10))
+	for i := 0; i < 2; i++ {
+		_, err := c.R().Get("/")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheTransport_Authorization(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/shared":
+			w.Header().Set("Cache-Control", "s-maxage=60")
+		case "/private":
+			w.Header().Set("Cache-Control", "private, must-revalidate, max-age=60")
+		case "/revalidate":
+			w.Header().Set("Cache-Control", "must-revalidate, max-age=60")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	c := newCacheTestComponent(server.URL, NewMemoryCacheStore(10))
+	// 带 Authorization 的请求，响应没有允许共享缓存时不缓存
+	res, err := c.R().SetHeader("Authorization", "Bearer u1").Get("/me")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer u1", res.String())
+	res, err = c.R().SetHeader("Authorization", "Bearer u2").Get("/me")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer u2", res.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	for i := 0; i < 2; i++ {
+		_, err = c.R().SetHeader("Authorization", "Bearer u1").Get("/public")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	for i := 0; i < 2; i++ {
+		_, err = c.R().SetHeader("Authorization", "Bearer u1").Get("/shared")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	// must-revalidate 不允许共享缓存带 Authorization 的请求
+	res, err = c.R().SetHeader("Authorization", "Bearer u1").Get("/revalidate")
+	require.NoError(t, err)
+	res, err = c.R().SetHeader("Authorization", "Bearer u2").Get("/revalidate")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer u2", res.String())
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+
+	// private 的响应不缓存
+	for _, auth := range []string{"Bearer u1", "Bearer u2", ""} {
+		res, err = c.R().SetHeader("Authorization", auth).Get("/private")
+		require.NoError(t, err)
+		assert.Equal(t, auth, res.String())
+	}
+	assert.Equal(t, int32(9), atomic.LoadInt32(&calls))
+}
+
+func TestCacheEntry_fresh(t *testing.T) {
+	now := time.Now()
+	entry := &cacheEntry{
+		Header:       http.Header{"Cache-Control": {"max-age=10"}, "Date": {now.UTC().Format(http.TimeFormat)}},
+		RequestTime:  now,
+		ResponseTime: now,
+	}
+	assert.True(t, entry.fresh(cacheControl{}, now.Add(5*time.Second)))
+	assert.False(t, entry.fresh(cacheControl{}, now.Add(11*time.Second)))
+	assert.False(t, entry.fresh(cacheControl{"max-age": "3"}, now.Add(5*time.Second)))
+	assert.False(t, entry.fresh(cacheControl{"min-fresh": "6"}, now.Add(5*time.Second)))
+	assert.True(t, entry.fresh(cacheControl{"max-stale": "5"}, now.Add(12*time.Second)))
+	assert.True(t, entry.fresh(cacheControl{"max-stale": ""}, now.Add(time.Hour)))
+
+	entry.Header.Set("Cache-Control", "max-age=10, must-revalidate")
+	assert.False(t, entry.fresh(cacheControl{"max-stale": ""}, now.Add(time.Hour)))
+
+	// s-maxage 优先于 max-age
+	entry.Header.Set("Cache-Control", "max-age=10, s-maxage=20")
+	assert.True(t, entry.fresh(cacheControl{}, now.Add(15*time.Second)))
+	assert.False(t, entry.fresh(cacheControl{}, now.Add(21*time.Second)))
+
+	// 使用 Expires
+	entry.Header = http.Header{
+		"Date":    {now.UTC().Format(http.TimeFormat)},
+		"Expires": {now.Add(time.Minute).UTC().Format(http.TimeFormat)},
+	}
+	assert.True(t, entry.fresh(cacheControl{}, now.Add(30*time.Second)))
+	assert.False(t, entry.fresh(cacheControl{}, now.Add(2*time.Minute)))
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cc := parseCacheControl(http.Header{"Cache-Control": {`Max-Age=60, no-cache`, `private="x"`}})
+	assert.Equal(t, cacheControl{"max-age": "60", "no-cache": "", "private": "x"}, cc)
+	seconds, ok := cc.seconds("max-age")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, seconds)
+	_, ok = cc.seconds("no-cache")
+	assert.False(t, ok)
+}
@@ -1,6 +1,8 @@
 package ehttp
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
@@ -12,6 +14,7 @@ import (
 	"github.com/gotomicro/ego/core/eapp"
 	"github.com/gotomicro/ego/core/elog"
 	"github.com/gotomicro/ego/core/eregistry"
+	"github.com/gotomicro/ego/internal/etls"
 )
 
 // PackageName 设置包名
@@ -23,7 +26,10 @@ type Component struct {
 	config *Config
 	logger *elog.Component
 	*resty.Client
-	builder resolver.Builder
+	builder      resolver.Builder
+	tls          *etls.Reloader   // 配置了证书或CA时使用，关闭时停止监听证书文件
+	streamClient *resty.Client    // 用于 SSE 等流式请求，不设置超时，不重试
+	hooks        interceptorHooks // 拦截器的 hooks，流式请求不解析响应，结束时需要手动执行 after hooks
 }
 
 func newComponent(name string, config *Config, logger *elog.Component) *Component {
@@ -47,11 +53,28 @@ func newComponent(name string, config *Config, logger *elog.Component) *Componen
 	}
 
 	// resty的默认方法，无法设置长连接个数，和是否开启长连接，这里重新构造http client。
-	interceptors := []interceptor{fixedInterceptor, logInterceptor, metricInterceptor, traceInterceptor}
+	interceptors := []interceptor{fixedInterceptor, balancerInterceptor, logInterceptor, metricInterceptor, traceInterceptor}
+	var tlsReloader *etls.Reloader
 	// 如果有设置自定义httpClient，那么不为空，使用用户自定义httpClient
 	if config.httpClient == nil {
 		// 如果用户没有设置，使用ego默认的httpClient
-		config.httpClient = &http.Client{Transport: createTransport(config), Jar: config.cookieJar}
+		transport := createTransport(config)
+		if err := setTransportProxy(transport, config); err != nil {
+			// 代理地址可能包含认证信息，不记录到日志
+			elog.Panic("parse proxy error", elog.FieldErr(err))
+		}
+		tlsReloader, err = setTransportTLS(transport, config, logger)
+		if err != nil {
+			elog.Panic("build tls config error", elog.FieldErr(err), elog.FieldKey(config.Addr))
+		}
+		var roundTripper http.RoundTripper = transport
+		if config.EnableHTTPCache {
+			roundTripper, err = newCacheTransport(name, config, transport)
+			if err != nil {
+				elog.Panic("build http cache error", elog.FieldErr(err), elog.FieldKey(config.HTTPCacheStore))
+			}
+		}
+		config.httpClient = &http.Client{Transport: roundTripper, Jar: config.cookieJar}
 	}
 
 	cli := resty.NewWithClient(config.httpClient).
@@ -59,28 +82,71 @@ func newComponent(name string, config *Config, logger *elog.Component) *Componen
 		SetTimeout(config.ReadTimeout).
 		SetHeader("app", eapp.Name()).
 		SetBaseURL(addr)
+	// 流式请求的响应时间不确定，使用不设置超时的 http client，超时由 context 控制
+	streamHTTPClient := *config.httpClient
+	streamHTTPClient.Timeout = 0
+	streamCli := resty.NewWithClient(&streamHTTPClient).
+		SetDebug(config.RawDebug).
+		SetHeader("app", eapp.Name()).
+		SetBaseURL(addr)
+
+	var hooks interceptorHooks
 	for _, interceptorValue := range interceptors {
 		onBefore, onAfter, onErr := interceptorValue(name, config, logger, resolverBuild)
 		if onBefore != nil {
-			cli.OnBeforeRequest(onBefore)
+			hooks.before = append(hooks.before, onBefore)
 		}
 		if onAfter != nil {
-			cli.OnAfterResponse(onAfter)
+			hooks.after = append(hooks.after, onAfter)
 		}
 		if onErr != nil {
-			cli.OnError(onErr)
+			hooks.errors = append(hooks.errors, onErr)
 		}
 	}
+	hooks.register(cli)
+	hooks.register(streamCli)
+	setRetry(config, cli, hooks.errors)
+	setSigner(getSigner(name, config), config.preRequestHook, cli, streamCli)
 
 	return &Component{
-		name:    name,
-		config:  config,
-		logger:  logger,
-		Client:  cli,
-		builder: builder,
+		name:         name,
+		config:       config,
+		logger:       logger,
+		Client:       cli,
+		builder:      builder,
+		tls:          tlsReloader,
+		streamClient: streamCli,
+		hooks:        hooks,
+	}
+}
+
+// interceptorHooks 拦截器的 hooks，同时注册到普通请求和流式请求的 client
+type interceptorHooks struct {
+	before []resty.RequestMiddleware
+	after  []resty.ResponseMiddleware
+	errors []resty.ErrorHook
+}
+
+func (h interceptorHooks) register(cli *resty.Client) {
+	for _, onBefore := range h.before {
+		cli.OnBeforeRequest(onBefore)
+	}
+	for _, onAfter := range h.after {
+		cli.OnAfterResponse(onAfter)
+	}
+	for _, onErr := range h.errors {
+		cli.OnError(onErr)
 	}
 }
 
+// Close 停止监听证书文件
+func (c *Component) Close() error {
+	if c.tls == nil {
+		return nil
+	}
+	return c.tls.Close()
+}
+
 func parseTarget(addr string) (eregistry.Target, error) {
 	target, err := url.Parse(addr)
 	if err != nil {
@@ -103,7 +169,7 @@ func parseTarget(addr string) (eregistry.Target, error) {
 
 func createTransport(config *Config) *http.Transport {
 	dialer := &net.Dialer{
-		Timeout:   30 * time.Second,
+		Timeout:   config.DialTimeout,
 		KeepAlive: 30 * time.Second,
 		DualStack: true,
 	}
@@ -114,9 +180,51 @@ func createTransport(config *Config) *http.Transport {
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          config.MaxIdleConns,
 		IdleConnTimeout:       config.IdleConnTimeout,
-		TLSHandshakeTimeout:   10 * time.Second,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
 		ExpectContinueTimeout: 1 * time.Second,
 		DisableKeepAlives:     !config.EnableKeepAlives,
 		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
 	}
 }
+
+// setTransportProxy 配置了代理地址时使用该代理，否则使用环境变量中的代理
+func setTransportProxy(transport *http.Transport, config *Config) error {
+	if config.Proxy == "" {
+		return nil
+	}
+	proxyURL, err := url.Parse(config.Proxy)
+	if err != nil {
+		// url.Error 包含完整的代理地址，只返回原因
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("invalid proxy, %w", err)
+	}
+	if proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return errors.New("invalid proxy, scheme and host are required")
+	}
+	transport.Proxy = http.ProxyURL(proxyURL)
+	return nil
+}
+
+// setTransportTLS 配置了证书、CA或其他TLS选项时，使用 etls 构建支持证书热更新的TLS配置
+// 没有配置时使用默认的TLS配置，返回的 Reloader 为空
+func setTransportTLS(transport *http.Transport, config *Config, logger *elog.Component) (*etls.Reloader, error) {
+	if len(config.TLSCAFiles) == 0 && config.TLSCertFile == "" && config.TLSKeyFile == "" &&
+		config.TLSServerName == "" && !config.TLSInsecureSkipVerify && config.TLSMinVersion == "" {
+		return nil, nil
+	}
+	minVersion, err := etls.Version(config.TLSMinVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsReloader, err := etls.NewReloader(config.TLSCertFile, config.TLSKeyFile, config.TLSCAFiles, true, logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := tlsReloader.ClientConfig(config.TLSServerName, config.TLSInsecureSkipVerify, nil)
+	tlsConfig.MinVersion = minVersion
+	transport.TLSClientConfig = tlsConfig
+	return tlsReloader, nil
+}
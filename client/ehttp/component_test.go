@@ -1,11 +1,26 @@
 package ehttp
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/gotomicro/ego/client/ehttp/resolver"
 	"github.com/gotomicro/ego/core/eapp"
@@ -48,3 +63,104 @@ func TestNewComponent(t *testing.T) {
 	assert.Equal(t, in.builder, out.builder)
 	// assert.Equal(t, in.Client, out.Client)
 }
+
+// issueCert 签发证书，parent 为空时签发自签名的CA，返回证书、私钥和PEM文件路径
+func issueCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		tpl.IsCA = true
+		tpl.KeyUsage |= x509.KeyUsageCertSign
+		tpl.BasicConstraintsValid = true
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return cert, key, certFile, keyFile
+}
+
+func TestNewComponent_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey, caFile, _ := issueCert(t, dir, "ca", nil, nil)
+	_, _, serverCertFile, serverKeyFile := issueCert(t, dir, "server", ca, caKey)
+	_, _, clientCertFile, clientKeyFile := issueCert(t, dir, "client", ca, caKey)
+
+	serverCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	require.NoError(t, err)
+	caPool := x509.NewCertPool()
+	caPool.AddCert(ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    caPool,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Addr = server.URL
+	config.TLSCAFiles = []string{caFile}
+	config.TLSCertFile = clientCertFile
+	config.TLSKeyFile = clientKeyFile
+	config.TLSMinVersion = "1.3"
+	cli := newComponent("test", config, elog.DefaultLogger)
+	defer cli.Close()
+	res, err := cli.R().Get("/hello")
+	require.NoError(t, err)
+	assert.Equal(t, "client", res.String())
+
+	// 没有客户端证书时握手失败
+	config = DefaultConfig()
+	config.Addr = server.URL
+	config.TLSCAFiles = []string{caFile}
+	noCert := newComponent("test", config, elog.DefaultLogger)
+	defer noCert.Close()
+	_, err = noCert.R().Get("/hello")
+	assert.Error(t, err)
+}
+
+func TestNewComponent_Proxy(t *testing.T) {
+	var proxyAuth, requestURI string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		requestURI = r.RequestURI
+		_, _ = w.Write([]byte("proxy"))
+	}))
+	defer proxy.Close()
+
+	config := DefaultConfig()
+	config.Addr = "http://example.com"
+	config.Proxy = "http://user:password@" + proxy.Listener.Addr().String()
+	cli := newComponent("test", config, elog.DefaultLogger)
+	res, err := cli.R().Get("/hello")
+	require.NoError(t, err)
+	assert.Equal(t, "proxy", res.String())
+	assert.Equal(t, "http://example.com/hello", requestURI)
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:password")), proxyAuth)
+
+	config = DefaultConfig()
+	config.Proxy = "127.0.0.1:8080"
+	assert.Panics(t, func() {
+		newComponent("test", config, elog.DefaultLogger)
+	})
+}
@@ -1,6 +1,7 @@
 package ehttp
 
 import (
+	"net/http"
 	"reflect"
 	"runtime"
 	"testing"
@@ -29,5 +30,17 @@ func Test_DefaultConfig(t *testing.T) {
 		PathRelabel:                nil,
 		cookieJar:                  nil,
 		httpClient:                 nil,
+		BalancerName:               BalancerRoundRobin,
+		EjectionConsecutiveErrors:  5,
+		EjectionDuration:           30 * time.Second,
+		RetryStatusCodes:           []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RetryOnNetworkError:        true,
+		RetryBackoff:               100 * time.Millisecond,
+		RetryMaxBackoff:            2 * time.Second,
+		DialTimeout:                30 * time.Second,
+		TLSHandshakeTimeout:        10 * time.Second,
+		SSEReconnectDelay:          3 * time.Second,
+		HTTPCacheStore:             HTTPCacheStoreMemory,
+		HTTPCacheSize:              1000,
 	}, DefaultConfig()))
 }
@@ -56,6 +56,10 @@ func logAccess(name string, config *Config, logger *elog.Component, req *resty.R
 		elog.FieldCost(cost),
 		elog.FieldAddr(u.Host),
 	)
+	// 开启重试时记录第几次尝试
+	if config.RetryMaxAttempts > 1 {
+		fields = append(fields, elog.Int("attempt", req.Attempt))
+	}
 
 	event := "normal"
 
@@ -152,8 +156,8 @@ func fixedInterceptor(name string, config *Config, logger *elog.Component, build
 				}
 			}
 		}
-		// 只有存在，才会更新
-		if builder.GetAddr() != "" {
+		// 只有存在，才会更新；解析出多个节点时由 balancerInterceptor 为每个请求选择节点
+		if builder.GetAddr() != "" && len(resolverNodes(builder)) == 0 {
 			cli.HostURL = builder.GetAddr()
 		}
 		req.SetContext(context.WithValue(context.WithValue(req.Context(), begKey{}, time.Now()), urlKey{}, u))
@@ -196,6 +200,9 @@ func metricInterceptor(name string, config *Config, logger *elog.Component, buil
 		method := res.Request.Method + "." + res.Request.Context().Value(urlKey{}).(*url.URL).Path
 		emetric.ClientHandleCounter.Inc(emetric.TypeHTTP, name, method, addr, http.StatusText(res.StatusCode()))
 		emetric.ClientHandleHistogram.Observe(res.Time().Seconds(), emetric.TypeHTTP, name, method, addr)
+		if config.RetryMaxAttempts > 1 {
+			emetric.ClientAttemptCounter.Inc(emetric.TypeHTTP, name, method, addr, http.StatusText(res.StatusCode()), strconv.Itoa(res.Request.Attempt))
+		}
 		return nil
 	}
 	errorFn := func(req *resty.Request, err error) {
@@ -205,6 +212,10 @@ func metricInterceptor(name string, config *Config, logger *elog.Component, buil
 		} else {
 			emetric.ClientHandleCounter.Inc(emetric.TypeHTTP, name, method, addr, "biz error")
 		}
+		// 有响应的尝试已经在 afterFn 中记录
+		if v, ok := err.(*resty.ResponseError); config.RetryMaxAttempts > 1 && (!ok || v.Response.RawResponse == nil) {
+			emetric.ClientAttemptCounter.Inc(emetric.TypeHTTP, name, method, addr, "network error", strconv.Itoa(req.Attempt))
+		}
 		emetric.ClientHandleHistogram.Observe(time.Since(beg(req.Context())).Seconds(), emetric.TypeHTTP, name, method, addr)
 	}
 	return nil, afterFn, errorFn
@@ -0,0 +1,133 @@
+package ehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gotomicro/ego/core/eerrors"
+	"github.com/gotomicro/ego/internal/ecode"
+)
+
+// RequestOption 设置请求，例如查询参数、header
+type RequestOption func(req *resty.Request)
+
+// WithQueryParam 设置查询参数
+func WithQueryParam(key, value string) RequestOption {
+	return func(req *resty.Request) {
+		req.SetQueryParam(key, value)
+	}
+}
+
+// WithRequestHeader 设置请求header
+func WithRequestHeader(key, value string) RequestOption {
+	return func(req *resty.Request) {
+		req.SetHeader(key, value)
+	}
+}
+
+// WithPathParam 设置路径参数，例如 /users/{id}
+func WithPathParam(key, value string) RequestOption {
+	return func(req *resty.Request) {
+		req.SetPathParam(key, value)
+	}
+}
+
+// WithErrorResult 响应状态码不是2xx时，将响应内容解析到 result，result 需要是指针
+func WithErrorResult(result interface{}) RequestOption {
+	return func(req *resty.Request) {
+		req.SetError(result)
+	}
+}
+
+// Get 发送GET请求，将响应内容解析为 T
+func Get[T any](ctx context.Context, c *Component, path string, opts ...RequestOption) (T, error) {
+	return do[T](ctx, c, http.MethodGet, path, nil, opts...)
+}
+
+// Delete 发送DELETE请求，将响应内容解析为 T
+func Delete[T any](ctx context.Context, c *Component, path string, opts ...RequestOption) (T, error) {
+	return do[T](ctx, c, http.MethodDelete, path, nil, opts...)
+}
+
+// PostJSON 以JSON格式发送POST请求，将响应内容解析为 Resp
+func PostJSON[Req any, Resp any](ctx context.Context, c *Component, path string, req Req, opts ...RequestOption) (Resp, error) {
+	return do[Resp](ctx, c, http.MethodPost, path, req, opts...)
+}
+
+// PutJSON 以JSON格式发送PUT请求，将响应内容解析为 Resp
+func PutJSON[Req any, Resp any](ctx context.Context, c *Component, path string, req Req, opts ...RequestOption) (Resp, error) {
+	return do[Resp](ctx, c, http.MethodPut, path, req, opts...)
+}
+
+// do 通过 Component.R() 发送请求，保留访问日志、指标和链路
+// 请求失败时返回 *eerrors.EgoError：
+// 网络错误转换为 Canceled、DeadlineExceeded 或 Unavailable；
+// 状态码不是2xx时按状态码转换错误码，响应内容是 EgoError 格式时使用其中的 reason、message、metadata
+func do[T any](ctx context.Context, c *Component, method, path string, body interface{}, opts ...RequestOption) (T, error) {
+	var result T
+	req := c.R().SetContext(ctx)
+	if body != nil {
+		req.SetHeader("Content-Type", "application/json").SetBody(body)
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	res, err := req.Execute(method, path)
+	if err != nil {
+		return result, transportError(err)
+	}
+	if res.StatusCode() < http.StatusOK || res.StatusCode() >= http.StatusMultipleChoices {
+		return result, responseError(res)
+	}
+	if len(res.Body()) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(res.Body(), &result); err != nil {
+		return result, eerrors.New(int(codes.Internal), eerrors.UnknownReason, "decode response fail, "+err.Error())
+	}
+	return result, nil
+}
+
+// transportError 没有响应的错误
+func transportError(err error) *eerrors.EgoError {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return eerrors.Canceled(eerrors.UnknownReason, err.Error())
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return eerrors.DeadlineExceeded(eerrors.UnknownReason, err.Error())
+	default:
+		return eerrors.New(int(codes.Unavailable), eerrors.UnknownReason, err.Error())
+	}
+}
+
+// responseError 状态码不是2xx的响应
+func responseError(res *resty.Response) *eerrors.EgoError {
+	return statusError(res.StatusCode(), res.Body())
+}
+
+// statusError 按状态码转换错误码，响应内容是 EgoError 格式时使用其中的 reason、message、metadata
+func statusError(statusCode int, content []byte) *eerrors.EgoError {
+	code := ecode.HTTPToGrpcStatusCode(statusCode)
+	if code == codes.OK {
+		code = codes.Unknown
+	}
+	egoErr := eerrors.New(int(code), eerrors.UnknownReason, string(content))
+	var body struct {
+		Reason   string            `json:"reason"`
+		Message  string            `json:"message"`
+		Metadata map[string]string `json:"metadata"`
+	}
+	if json.Unmarshal(content, &body) == nil && (body.Reason != "" || body.Message != "") {
+		egoErr.Reason = body.Reason
+		egoErr.Message = body.Message
+		egoErr.Metadata = body.Metadata
+	}
+	return egoErr
+}
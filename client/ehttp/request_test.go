@@ -0,0 +1,122 @@
+package ehttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gotomicro/ego/core/eerrors"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+type testUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type testBizError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func newRequestTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/1", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(testUser{ID: 1, Name: r.URL.Query().Get("name")})
+	})
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		var user testUser
+		_ = json.NewDecoder(r.Body).Decode(&user)
+		user.ID = 2
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(user)
+	})
+	mux.HandleFunc("/forbidden", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"reason":"USER_FORBIDDEN","message":"forbidden","metadata":{"uid":"1"}}`))
+	})
+	mux.HandleFunc("/biz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":10001,"msg":"invalid name"}`))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return httptest.NewServer(mux)
+}
+
+func newRequestTestComponent(addr string) *Component {
+	config := DefaultConfig()
+	config.Addr = addr
+	return newComponent("test", config, elog.DefaultLogger)
+}
+
+func TestGet(t *testing.T) {
+	server := newRequestTestServer()
+	defer server.Close()
+	c := newRequestTestComponent(server.URL)
+
+	user, err := Get[testUser](context.Background(), c, "/users/{id}", WithPathParam("id", "1"), WithQueryParam("name", "ego"))
+	require.NoError(t, err)
+	assert.Equal(t, testUser{ID: 1, Name: "ego"}, user)
+
+	user, err = Get[testUser](context.Background(), c, "/empty")
+	require.NoError(t, err)
+	assert.Equal(t, testUser{}, user)
+
+	_, err = Get[testUser](context.Background(), c, "/not-found")
+	assert.Equal(t, int32(codes.NotFound), eerrors.FromError(err).Code)
+}
+
+func TestPostJSON(t *testing.T) {
+	server := newRequestTestServer()
+	defer server.Close()
+	c := newRequestTestComponent(server.URL)
+
+	user, err := PostJSON[testUser, testUser](context.Background(), c, "/users", testUser{Name: "ego"})
+	require.NoError(t, err)
+	assert.Equal(t, testUser{ID: 2, Name: "ego"}, user)
+}
+
+func TestRequest_Error(t *testing.T) {
+	server := newRequestTestServer()
+	defer server.Close()
+	c := newRequestTestComponent(server.URL)
+
+	// 响应内容是 EgoError 格式
+	_, err := Get[testUser](context.Background(), c, "/forbidden")
+	egoErr := eerrors.FromError(err)
+	assert.Equal(t, int32(codes.PermissionDenied), egoErr.Code)
+	assert.Equal(t, "USER_FORBIDDEN", egoErr.Reason)
+	assert.Equal(t, "forbidden", egoErr.Message)
+	assert.Equal(t, map[string]string{"uid": "1"}, egoErr.Metadata)
+
+	// 解析到自定义的错误类型
+	var bizErr testBizError
+	_, err = PostJSON[testUser, testUser](context.Background(), c, "/biz", testUser{}, WithErrorResult(&bizErr))
+	assert.Equal(t, int32(codes.InvalidArgument), eerrors.FromError(err).Code)
+	assert.Equal(t, testBizError{Code: 10001, Msg: "invalid name"}, bizErr)
+
+	// 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = Get[testUser](ctx, c, "/slow")
+	assert.Equal(t, int32(codes.DeadlineExceeded), eerrors.FromError(err).Code)
+
+	// 连接失败
+	server.Close()
+	_, err = Get[testUser](context.Background(), c, "/users/1")
+	assert.Equal(t, int32(codes.Unavailable), eerrors.FromError(err).Code)
+}
@@ -3,7 +3,9 @@ package resolver
 import (
 	"context"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
 
 	"google.golang.org/grpc/attributes"
 	"google.golang.org/grpc/resolver"
@@ -30,10 +32,25 @@ type Builder interface {
 	Scheme() string
 }
 
+// Resolver 解析地址
 type Resolver interface {
+	// GetAddr 返回任意一个节点的地址
 	GetAddr() string
 }
 
+// NodesResolver 可以返回所有节点的解析器，实现该接口的解析器解析出多个节点时，客户端为每个请求选择节点
+type NodesResolver interface {
+	Resolver
+	// GetNodes 返回所有节点
+	GetNodes() []Node
+}
+
+// Node 节点信息
+type Node struct {
+	Addr   string // 节点地址，例如 http://127.0.0.1:9001
+	Weight int    // 节点权重，默认100
+}
+
 // Register ...
 func Register(name string, reg eregistry.Registry) {
 	b := &baseBuilder{
@@ -104,16 +121,27 @@ type baseResolver struct {
 	reg    eregistry.Registry
 	cancel context.CancelFunc
 	// addrSlices []string
+	mu       sync.RWMutex
 	nodeInfo map[string]*attributes.Attributes // node节点的属性
+	nodes    []Node
 }
 
 func (b *baseResolver) GetAddr() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	for key := range b.nodeInfo {
 		return "http://" + key
 	}
 	return ""
 }
 
+// GetNodes 返回所有节点，按地址排序
+func (b *baseResolver) GetNodes() []Node {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.nodes
+}
+
 // Close ...
 func (b *baseResolver) Close() {
 	b.stop <- struct{}{}
@@ -162,6 +190,8 @@ func (b *baseResolver) run(endpoints chan eregistry.Endpoints) {
 
 // tryUpdateAttrs 更新节点数据
 func (b *baseResolver) tryUpdateAttrs(nodes map[string]server.ServiceInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	for addr, node := range nodes {
 		oldAttr, ok := b.nodeInfo[addr]
 		newAttr := attributes.New(constant.KeyServiceInfo, node)
@@ -174,4 +204,17 @@ func (b *baseResolver) tryUpdateAttrs(nodes map[string]server.ServiceInfo) {
 			delete(b.nodeInfo, addr)
 		}
 	}
+	// 每次生成新的切片，GetNodes 返回后不会被修改
+	newNodes := make([]Node, 0, len(nodes))
+	for addr, node := range nodes {
+		weight := int(node.Weight)
+		if weight <= 0 {
+			weight = 100
+		}
+		newNodes = append(newNodes, Node{Addr: "http://" + addr, Weight: weight})
+	}
+	sort.Slice(newNodes, func(i, j int) bool {
+		return newNodes[i].Addr < newNodes[j].Addr
+	})
+	b.nodes = newNodes
 }
@@ -0,0 +1,96 @@
+package ehttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/gotomicro/ego/internal/retry"
+)
+
+// idempotentMethods 幂等的请求方法，默认只重试这些方法
+var idempotentMethods = map[string]struct{}{
+	http.MethodGet:     {},
+	http.MethodHead:    {},
+	http.MethodOptions: {},
+	http.MethodTrace:   {},
+	http.MethodPut:     {},
+	http.MethodDelete:  {},
+}
+
+// setRetry 使用 resty 的重试，每次尝试都会执行拦截器
+// 每次尝试的 OnBeforeRequest、OnAfterResponse 都会执行，OnError 只在最后一次尝试后执行，
+// 所以没有响应的中间尝试，需要在重试 hook 里执行拦截器的 errorHooks，保证日志、指标、链路完整
+func setRetry(config *Config, cli *resty.Client, errorHooks []resty.ErrorHook) {
+	if config.RetryMaxAttempts <= 1 {
+		return
+	}
+	retryStatusCodes := make(map[int]struct{}, len(config.RetryStatusCodes))
+	for _, code := range config.RetryStatusCodes {
+		retryStatusCodes[code] = struct{}{}
+	}
+	backoff := retry.Options{BackoffMultiplier: 2, BackoffMinDuration: config.RetryBackoff}
+
+	cli.SetRetryCount(config.RetryMaxAttempts - 1).
+		SetRetryWaitTime(config.RetryBackoff).
+		SetRetryMaxWaitTime(config.RetryMaxBackoff).
+		AddRetryCondition(func(res *resty.Response, err error) bool {
+			// res 为空时是拦截器返回的错误，不重试
+			if res == nil {
+				return false
+			}
+			if _, ok := idempotentMethods[res.Request.Method]; !ok && !config.RetryNonIdempotent {
+				return false
+			}
+			if res.RawResponse == nil {
+				return err != nil && config.RetryOnNetworkError
+			}
+			_, ok := retryStatusCodes[res.StatusCode()]
+			return ok
+		}).
+		SetRetryAfter(retryAfter(backoff)).
+		AddRetryHook(func(res *resty.Response, err error) {
+			// 最后一次尝试由 OnError 处理
+			if res == nil || res.RawResponse != nil || err == nil || res.Request.Attempt >= config.RetryMaxAttempts {
+				return
+			}
+			for _, hook := range errorHooks {
+				hook(res.Request, &resty.ResponseError{Response: res, Err: err})
+			}
+		})
+}
+
+// retryAfter 返回重试前的等待时间，优先使用响应的 Retry-After
+// resty 的 Attempt 从1开始，第一次重试等待 RetryBackoff，之后按指数增长
+func retryAfter(backoff retry.Options) resty.RetryAfterFunc {
+	return func(cli *resty.Client, res *resty.Response) (time.Duration, error) {
+		if wait, ok := parseRetryAfter(res.Header().Get("Retry-After")); ok {
+			return wait, nil
+		}
+		return retry.Delay(res.Request.Attempt-1, backoff), nil
+	}
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数和 HTTP 日期两种格式
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	wait := time.Until(date)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
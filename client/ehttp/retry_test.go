@@ -0,0 +1,152 @@
+package ehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/internal/retry"
+)
+
+func newRetryComponent(addr string, opts ...func(config *Config)) *Component {
+	config := DefaultConfig()
+	config.Addr = addr
+	config.RetryMaxAttempts = 3
+	config.RetryBackoff = time.Millisecond
+	config.RetryMaxBackoff = 10 * time.Millisecond
+	for _, opt := range opts {
+		opt(config)
+	}
+	return newComponent("test", config, elog.DefaultLogger)
+}
+
+func TestRetry_StatusCode(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	cli := newRetryComponent(server.URL)
+	res, err := cli.R().Get("/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, 3, res.Request.Attempt)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// 非幂等请求默认不重试
+	atomic.StoreInt32(&calls, 0)
+	res, err = cli.R().Post("/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 不在 RetryStatusCodes 中的状态码不重试
+	atomic.StoreInt32(&calls, 0)
+	cli = newRetryComponent(server.URL, func(config *Config) {
+		config.RetryStatusCodes = []int{http.StatusInternalServerError}
+	})
+	res, err = cli.R().Get("/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	var calls int32
+	var first time.Time
+	var gap time.Duration
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gap = time.Since(first)
+	}))
+	defer server.Close()
+
+	cli := newRetryComponent(server.URL, func(config *Config) {
+		config.RetryMaxBackoff = 5 * time.Second
+	})
+	res, err := cli.R().Get("/hello")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.GreaterOrEqual(t, gap, time.Second)
+}
+
+func TestRetry_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	addr := server.URL
+	server.Close()
+
+	var errors []int
+	config := DefaultConfig()
+	config.RetryMaxAttempts = 3
+	config.RetryBackoff = time.Millisecond
+	config.RetryMaxBackoff = 10 * time.Millisecond
+	cli := resty.New().SetBaseURL(addr)
+	hook := func(req *resty.Request, err error) {
+		errors = append(errors, req.Attempt)
+	}
+	cli.OnError(hook)
+	setRetry(config, cli, []resty.ErrorHook{hook})
+	_, err := cli.R().Get("/hello")
+	assert.Error(t, err)
+	// 每次尝试都会执行一次 errorHook
+	assert.Equal(t, []int{1, 2, 3}, errors)
+
+	config.RetryOnNetworkError = false
+	errors = nil
+	cli = resty.New().SetBaseURL(addr)
+	cli.OnError(hook)
+	setRetry(config, cli, []resty.ErrorHook{hook})
+	_, err = cli.R().Get("/hello")
+	assert.Error(t, err)
+	assert.Equal(t, []int{1}, errors)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("3")
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Minute, wait, float64(2*time.Second))
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("-1")
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+func TestRetryAfter(t *testing.T) {
+	fn := retryAfter(retry.Options{BackoffMultiplier: 2, BackoffMinDuration: 100 * time.Millisecond})
+	delay := func(attempt int) time.Duration {
+		res := &resty.Response{Request: &resty.Request{Attempt: attempt}, RawResponse: &http.Response{Header: http.Header{}}}
+		wait, err := fn(nil, res)
+		assert.NoError(t, err)
+		return wait
+	}
+	// 第一次重试等待 RetryBackoff，包含最多40%的抖动
+	first := delay(1)
+	assert.LessOrEqual(t, first, 100*time.Millisecond)
+	assert.GreaterOrEqual(t, first, 60*time.Millisecond)
+	second := delay(2)
+	assert.LessOrEqual(t, second, 200*time.Millisecond)
+	assert.GreaterOrEqual(t, second, 120*time.Millisecond)
+}
@@ -0,0 +1,149 @@
+package ehttp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/gotomicro/ego/internal/esign"
+)
+
+// Signer 请求签名，在拦截器之后、请求发送之前执行，每次重试都会重新签名
+// 可以通过 req.GetBody 重复读取请求内容
+// 签名占用了 resty 的 PreRequestHook，不要再调用 SetPreRequestHook，否则签名会被覆盖，需要时使用 WithPreRequestHook
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// SignerFunc 函数形式的 Signer
+type SignerFunc func(req *http.Request) error
+
+// Sign ...
+func (f SignerFunc) Sign(req *http.Request) error {
+	return f(req)
+}
+
+var (
+	signersMu sync.RWMutex
+	signers   = map[string]Signer{}
+)
+
+// RegisterSigner 注册客户端的签名器，name 为客户端的配置名，例如 Load("http.webhook") 对应 "http.webhook"
+// 需要在 Build 之前注册，优先级低于 WithSigner，高于配置的 SignKeyID、SignSecret
+func RegisterSigner(name string, signer Signer) {
+	signersMu.Lock()
+	defer signersMu.Unlock()
+	signers[name] = signer
+}
+
+func getSigner(name string, config *Config) Signer {
+	if config.signer != nil {
+		return config.signer
+	}
+	signersMu.RLock()
+	signer, ok := signers[name]
+	signersMu.RUnlock()
+	if ok {
+		return signer
+	}
+	if config.SignKeyID != "" && config.SignSecret != "" {
+		return NewHMACSigner(config.SignKeyID, config.SignSecret, config.SignHeaders...)
+	}
+	return nil
+}
+
+// setSigner 签名需要最终的请求地址和请求内容，所以使用 resty 的 PreRequestHook，而不是 OnBeforeRequest
+// resty 只有一个 PreRequestHook，所以 WithPreRequestHook 设置的 hook 在这里和签名串联，先执行 hook 再签名
+func setSigner(signer Signer, hook resty.PreRequestHook, clients ...*resty.Client) {
+	if signer == nil && hook == nil {
+		return
+	}
+	for _, cli := range clients {
+		cli.SetPreRequestHook(func(c *resty.Client, req *http.Request) error {
+			if hook != nil {
+				if err := hook(c, req); err != nil {
+					return err
+				}
+			}
+			if signer == nil {
+				return nil
+			}
+			return signer.Sign(req)
+		})
+	}
+}
+
+// hmacSigner 参考 AWS SigV4 的 HMAC-SHA256 签名，服务端使用 egin 的 SignatureMiddleware 校验
+type hmacSigner struct {
+	keyID         string
+	secret        []byte
+	signedHeaders []string
+}
+
+// NewHMACSigner 创建 HMAC-SHA256 签名器，signedHeaders 为除 host、x-ego-content-sha256、x-ego-date、x-ego-nonce 外额外参与签名的 header
+func NewHMACSigner(keyID, secret string, signedHeaders ...string) Signer {
+	return &hmacSigner{
+		keyID:         keyID,
+		secret:        []byte(secret),
+		signedHeaders: esign.NormalizeHeaders(append(append([]string{}, esign.DefaultSignedHeaders...), signedHeaders...)),
+	}
+}
+
+// Sign 设置 X-Ego-Date、X-Ego-Nonce、X-Ego-Content-Sha256 和 X-Ego-Signature
+func (s *hmacSigner) Sign(req *http.Request) error {
+	payload, err := readRequestBody(req)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	now := time.Now()
+	payloadHash := esign.HashPayload(payload)
+	req.Header.Set(esign.HeaderDate, now.UTC().Format(esign.TimeFormat))
+	req.Header.Set(esign.HeaderNonce, hex.EncodeToString(nonce))
+	req.Header.Set(esign.HeaderContentSha256, payloadHash)
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	canonicalRequest := esign.CanonicalRequest(req.Method, req.URL, host, req.Header, s.signedHeaders, payloadHash)
+	req.Header.Set(esign.HeaderSignature, esign.Authorization{
+		KeyID:         s.keyID,
+		SignedHeaders: s.signedHeaders,
+		Signature:     esign.Sign(s.secret, now, canonicalRequest),
+	}.String())
+	return nil
+}
+
+// readRequestBody 读取请求内容，读取后请求内容仍然可以发送
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil || body == nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	payload, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(payload))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(payload)), nil
+	}
+	return payload, nil
+}
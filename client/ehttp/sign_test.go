@@ -0,0 +1,97 @@
+package ehttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/internal/esign"
+)
+
+func TestHMACSigner(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"id":1}`, string(body))
+		headers = append(headers, r.Header.Clone())
+		auth, err := esign.ParseAuthorization(r.Header.Get(esign.HeaderSignature))
+		require.NoError(t, err)
+		assert.Equal(t, "order", auth.KeyID)
+		assert.Equal(t, []string{"host", "x-ego-content-sha256", "x-ego-date", "x-ego-nonce", "x-tenant"}, auth.SignedHeaders)
+		assert.Equal(t, esign.HashPayload(body), r.Header.Get(esign.HeaderContentSha256))
+		date, err := time.Parse(esign.TimeFormat, r.Header.Get(esign.HeaderDate))
+		require.NoError(t, err)
+		canonical := esign.CanonicalRequest(r.Method, r.URL, r.Host, r.Header, auth.SignedHeaders, esign.HashPayload(body))
+		assert.Equal(t, esign.Sign([]byte("secret"), date, canonical), auth.Signature)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Addr = server.URL
+	config.SignKeyID = "order"
+	config.SignSecret = "secret"
+	config.SignHeaders = []string{"X-Tenant"}
+	c := newComponent("test-sign", config, elog.DefaultLogger)
+	for i := 0; i < 2; i++ {
+		_, err := c.R().SetHeader("X-Tenant", "ego").SetQueryParam("v", "1").SetBody(strings.NewReader(`{"id":1}`)).Post("/hooks")
+		require.NoError(t, err)
+	}
+	require.Len(t, headers, 2)
+	// 每次请求使用不同的随机数
+	assert.NotEqual(t, headers[0].Get(esign.HeaderNonce), headers[1].Get(esign.HeaderNonce))
+}
+
+func TestGetSigner(t *testing.T) {
+	config := DefaultConfig()
+	assert.Nil(t, getSigner("test-no-signer", config))
+
+	config.SignKeyID = "order"
+	config.SignSecret = "secret"
+	assert.IsType(t, &hmacSigner{}, getSigner("test-no-signer", config))
+
+	registered := SignerFunc(func(req *http.Request) error { return nil })
+	RegisterSigner("test-registered-signer", registered)
+	assert.NotNil(t, getSigner("test-registered-signer", config))
+	assert.IsType(t, SignerFunc(nil), getSigner("test-registered-signer", config))
+
+	var called bool
+	config.signer = SignerFunc(func(req *http.Request) error {
+		called = true
+		return nil
+	})
+	_ = getSigner("test-registered-signer", config).Sign(nil)
+	assert.True(t, called)
+}
+
+func TestSetSignerPreRequestHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// hook 设置的 header 参与签名
+		auth, err := esign.ParseAuthorization(r.Header.Get(esign.HeaderSignature))
+		require.NoError(t, err)
+		assert.Contains(t, auth.SignedHeaders, "x-tenant")
+		assert.Equal(t, "ego", r.Header.Get("X-Tenant"))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Addr = server.URL
+	config.SignKeyID = "order"
+	config.SignSecret = "secret"
+	config.SignHeaders = []string{"X-Tenant"}
+	config.preRequestHook = func(_ *resty.Client, req *http.Request) error {
+		req.Header.Set("X-Tenant", "ego")
+		return nil
+	}
+	c := newComponent("test-sign-hook", config, elog.DefaultLogger)
+	res, err := c.R().Get("/hooks")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+}
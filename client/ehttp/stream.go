@@ -0,0 +1,261 @@
+package ehttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gotomicro/ego/core/eerrors"
+	"github.com/gotomicro/ego/core/emetric"
+)
+
+// maxStreamErrorBody 流式请求失败时最多读取的响应内容
+const maxStreamErrorBody = 64 * 1024
+
+// Event SSE 事件
+type Event struct {
+	ID    string // 事件ID，断开重连时通过 Last-Event-ID 发送给服务端
+	Event string // 事件类型，服务端没有设置时为空
+	Data  []byte // 事件数据，多行 data 使用\n连接
+}
+
+// stream 一次流式请求，不解析响应，由调用方按行读取响应内容
+// resty 不会对不解析响应的请求执行 OnAfterResponse，连接关闭时手动执行拦截器的 hooks，保留访问日志、指标和链路
+type stream struct {
+	c        *Component
+	res      *resty.Response
+	reader   *bufio.Reader
+	method   string
+	peer     string
+	messages int
+}
+
+// openStream 发送流式请求，状态码不是2xx时读取响应内容并返回错误
+// 返回的 bool 表示是否可以重连，只有没有响应的连接错误可以重连
+func (c *Component) openStream(ctx context.Context, method, path string, body interface{}, accept string, opts ...RequestOption) (*stream, bool, error) {
+	req := c.streamClient.R().SetContext(ctx).SetDoNotParseResponse(true).SetHeader("Accept", accept)
+	if body != nil {
+		req.SetHeader("Content-Type", "application/json").SetBody(body)
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	res, err := req.Execute(method, path)
+	if err != nil {
+		return nil, ctx.Err() == nil, transportError(err)
+	}
+	s := &stream{
+		c:      c,
+		res:    res,
+		reader: bufio.NewReader(res.RawBody()),
+		method: res.Request.Method,
+		peer:   strings.TrimRight(c.config.Addr, "/"),
+	}
+	if u, ok := res.Request.Context().Value(urlKey{}).(*url.URL); ok {
+		s.method += "." + u.Path
+	}
+	trace.SpanFromContext(res.Request.Context()).AddEvent("connect")
+	if c.config.EnableMetricInterceptor {
+		emetric.ClientStreamConnCounter.Inc(emetric.TypeHTTP, c.name, s.method, s.peer, "connect")
+	}
+	if res.StatusCode() < http.StatusOK || res.StatusCode() >= http.StatusMultipleChoices {
+		content, _ := io.ReadAll(io.LimitReader(s.reader, maxStreamErrorBody))
+		s.close(nil)
+		return nil, false, statusError(res.StatusCode(), content)
+	}
+	return s, false, nil
+}
+
+// readLine 读取一行，去掉行尾的\n或\r\n
+func (s *stream) readLine() ([]byte, error) {
+	line, err := s.reader.ReadBytes('\n')
+	if len(line) > 0 && err == io.EOF {
+		// 最后一行没有换行符
+		err = nil
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return line, err
+}
+
+// recv 记录收到的消息
+func (s *stream) recv() {
+	s.messages++
+	if s.c.config.EnableMetricInterceptor {
+		emetric.ClientStreamMsgCounter.Inc(s.c.name, s.method, s.peer, emetric.DirectionRecv)
+	}
+}
+
+// close 关闭连接并执行拦截器的 hooks，err 为空或 io.EOF 表示正常结束
+func (s *stream) close(err error) {
+	_ = s.res.RawBody().Close()
+	span := trace.SpanFromContext(s.res.Request.Context())
+	span.SetAttributes(attribute.Int("messages", s.messages))
+	span.AddEvent("disconnect")
+	if s.c.config.EnableMetricInterceptor {
+		emetric.ClientStreamConnCounter.Inc(emetric.TypeHTTP, s.c.name, s.method, s.peer, "disconnect")
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		for _, hook := range s.c.hooks.after {
+			_ = hook(s.c.streamClient, s.res)
+		}
+		return
+	}
+	for _, hook := range s.c.hooks.errors {
+		hook(s.res.Request, &resty.ResponseError{Response: s.res, Err: err})
+	}
+}
+
+// SSE 发送 Server-Sent Events 请求，每收到一个事件调用一次 handler
+// 连接断开后按 SSEReconnectDelay 或服务端设置的 retry 重连，并通过 Last-Event-ID 发送最后收到的事件ID
+// handler 返回错误、context 结束、服务端返回204或其他非2xx状态码时不再重连
+func (c *Component) SSE(ctx context.Context, method, path string, body interface{}, handler func(event Event) error, opts ...RequestOption) error {
+	var (
+		lastEventID string
+		delay       = c.config.SSEReconnectDelay
+		failures    int
+	)
+	for {
+		reqOpts := opts
+		if lastEventID != "" {
+			reqOpts = append(opts[:len(opts):len(opts)], WithRequestHeader("Last-Event-ID", lastEventID))
+		}
+		s, retryable, err := c.openStream(ctx, method, path, body, "text/event-stream", reqOpts...)
+		if err != nil && !retryable {
+			return err
+		}
+		if err == nil {
+			if s.res.StatusCode() == http.StatusNoContent {
+				s.close(nil)
+				return nil
+			}
+			if mediaType, _, _ := mime.ParseMediaType(s.res.Header().Get("Content-Type")); mediaType != "text/event-stream" {
+				s.close(nil)
+				return eerrors.New(int(codes.Internal), eerrors.UnknownReason, "unexpected content type "+s.res.Header().Get("Content-Type"))
+			}
+			failures = 0
+			var handlerErr error
+			err = s.readEvents(&lastEventID, &delay, func(event Event) error {
+				handlerErr = handler(event)
+				return handlerErr
+			})
+			if handlerErr != nil {
+				s.close(nil)
+				return handlerErr
+			}
+			s.close(err)
+			if err != nil && !errors.Is(err, io.EOF) {
+				err = transportError(err)
+			}
+		}
+		if ctx.Err() != nil {
+			return transportError(ctx.Err())
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			failures++
+			if c.config.SSEMaxReconnects > 0 && failures > c.config.SSEMaxReconnects {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return transportError(ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// readEvents 按 https://html.spec.whatwg.org/multipage/server-sent-events.html 解析事件
+func (s *stream) readEvents(lastEventID *string, delay *time.Duration, handler func(event Event) error) error {
+	var (
+		event Event
+		data  bytes.Buffer
+	)
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		// 空行表示事件结束
+		if len(line) == 0 {
+			if data.Len() > 0 {
+				event.ID = *lastEventID
+				event.Data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+				s.recv()
+				if err := handler(event); err != nil {
+					return err
+				}
+			}
+			event = Event{}
+			data = bytes.Buffer{}
+			continue
+		}
+		// 注释
+		if line[0] == ':' {
+			continue
+		}
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			event.Event = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				*lastEventID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(string(value)); err == nil && ms >= 0 {
+				*delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// StreamJSON 发送请求并按行解析 JSON（NDJSON）响应，每解析一行调用一次 handler，不会重连
+func StreamJSON[T any](ctx context.Context, c *Component, method, path string, body interface{}, handler func(item T) error, opts ...RequestOption) error {
+	s, _, err := c.openStream(ctx, method, path, body, "application/x-ndjson", opts...)
+	if err != nil {
+		return err
+	}
+	for {
+		line, err := s.readLine()
+		if errors.Is(err, io.EOF) {
+			s.close(nil)
+			return nil
+		}
+		if err != nil {
+			s.close(err)
+			return transportError(err)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var item T
+		if err := json.Unmarshal(line, &item); err != nil {
+			s.close(err)
+			return eerrors.New(int(codes.Internal), eerrors.UnknownReason, "decode stream item fail, "+err.Error())
+		}
+		s.recv()
+		if err := handler(item); err != nil {
+			s.close(nil)
+			return err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package ehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/gotomicro/ego/core/eerrors"
+)
+
+func TestComponent_SSE(t *testing.T) {
+	var connects int32
+	var lastEventIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&connects, 1)
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		if n == 1 {
+			_, _ = fmt.Fprint(w, ": comment\nretry: 10\n\nid: 1\nevent: add\ndata: hello\ndata: world\n\n")
+			w.(http.Flusher).Flush()
+			_, _ = fmt.Fprint(w, "id: 2\r\ndata: ego\r\n\r\n")
+			return
+		}
+		_, _ = fmt.Fprint(w, "id: 3\ndata: done\n\n")
+	}))
+	defer server.Close()
+
+	c := newRequestTestComponent(server.URL)
+	stop := errors.New("stop")
+	var events []Event
+	err := c.SSE(context.Background(), http.MethodGet, "/events", nil, func(event Event) error {
+		events = append(events, event)
+		if event.ID == "3" {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, Event{ID: "1", Event: "add", Data: []byte("hello\nworld")}, events[0])
+	assert.Equal(t, Event{ID: "2", Data: []byte("ego")}, events[1])
+	assert.Equal(t, Event{ID: "3", Data: []byte("done")}, events[2])
+	// 重连时发送最后收到的事件ID
+	assert.Equal(t, []string{"", "2"}, lastEventIDs)
+}
+
+func TestComponent_SSE_Stop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		case "/not-found":
+			http.NotFound(w, r)
+		default:
+			_, _ = w.Write([]byte("plain"))
+		}
+	}))
+	defer server.Close()
+
+	c := newRequestTestComponent(server.URL)
+	handler := func(event Event) error { return nil }
+	// 服务端返回204时不再重连
+	assert.NoError(t, c.SSE(context.Background(), http.MethodGet, "/no-content", nil, handler))
+	err := c.SSE(context.Background(), http.MethodGet, "/not-found", nil, handler)
+	assert.Equal(t, int32(codes.NotFound), eerrors.FromError(err).Code)
+	err = c.SSE(context.Background(), http.MethodGet, "/plain", nil, handler)
+	assert.Equal(t, int32(codes.Internal), eerrors.FromError(err).Code)
+}
+
+func TestComponent_SSE_MaxReconnects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	addr := server.URL
+	server.Close()
+
+	c := newRequestTestComponent(addr)
+	c.config.SSEReconnectDelay = time.Millisecond
+	c.config.SSEMaxReconnects = 2
+	err := c.SSE(context.Background(), http.MethodGet, "/events", nil, func(event Event) error { return nil })
+	assert.Equal(t, int32(codes.Unavailable), eerrors.FromError(err).Code)
+}
+
+func TestStreamJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= 3; i++ {
+			_, _ = fmt.Fprintf(w, "{\"id\":%d,\"name\":\"user%d\"}\n", i, i)
+			w.(http.Flusher).Flush()
+		}
+		_, _ = fmt.Fprint(w, "\n{\"id\":4}")
+	}))
+	defer server.Close()
+
+	c := newRequestTestComponent(server.URL)
+	var users []testUser
+	err := StreamJSON[testUser](context.Background(), c, http.MethodPost, "/users", testUser{Name: "ego"}, func(user testUser) error {
+		users = append(users, user)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []testUser{{ID: 1, Name: "user1"}, {ID: 2, Name: "user2"}, {ID: 3, Name: "user3"}, {ID: 4}}, users)
+}
@@ -48,19 +48,17 @@ func Register{{.ServiceType}}HTTPServer(r *egin.Component, srv {{.ServiceType}}H
 func _{{$svrType}}_{{.Name}}{{.Num}}_HTTP_Handler(srv {{$svrType}}HTTPServer) gin.HandlerFunc  {
 	return func(ctx *gin.Context) {
 		var in {{.Request}}
+		// uri、query 绑定到整个请求，请求内容绑定到 body 指定的字段
+		// 绑定失败时返回 InvalidArgument 错误，metadata 中为字段的校验错误
 		{{- if .HasBody}}
-		if err := ctx.Bind(&in{{.Body}}); err != nil {
-			gErr := eerrors.FromError(err)
-			ctx.JSON(gErr.ToHTTPStatusCode(), gErr)
-			return
-		}
+		if err := egin.BindWithBody(ctx, &in, &in{{.Body}}); err != nil {
 		{{- else}}
-		if err := ctx.BindQuery(&in{{.Body}}); err != nil {
+		if err := egin.BindWithBody(ctx, &in, nil); err != nil {
+		{{- end}}
 			gErr := eerrors.FromError(err)
 			ctx.JSON(gErr.ToHTTPStatusCode(), gErr)
 			return
 		}
-		{{- end}}
 
 		reps, err := srv.{{.Name}}(ctx, &in)
 		if err != nil {
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestServiceDescExecute(t *testing.T) {
+	sd := &serviceDesc{
+		ServiceType: "Greeter",
+		ServiceName: "helloworld.Greeter",
+		Metadata:    "helloworld.proto",
+		Methods: []*methodDesc{
+			{Name: "GetHello", Request: "HelloRequest", Reply: "HelloReply", Path: "/hello/:id", Method: "GET", HasVars: true},
+			{Name: "CreateHello", Request: "HelloRequest", Reply: "HelloReply", Path: "/hello", Method: "POST", HasBody: true},
+			{Name: "UpdateHello", Request: "UpdateHelloRequest", Reply: "HelloReply", Path: "/hello/:id", Method: "PUT", HasVars: true, HasBody: true, Body: ".Hello"},
+		},
+	}
+	got := sd.execute()
+
+	golden := filepath.Join("testdata", "greeter_http.golden")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, []byte(got), 0644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
@@ -0,0 +1,207 @@
+// Package ehealth 健康检查注册中心
+// 客户端、定时任务和业务代码注册检查，治理服务和 HTTP 服务通过 /healthz、/readyz、/livez 暴露检查结果，
+// gRPC 服务根据检查结果设置 grpc.health.v1 中每个服务的状态
+package ehealth
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// DefaultTimeout 每个检查默认的超时时间
+const DefaultTimeout = 3 * time.Second
+
+// Kind 检查类型
+type Kind string
+
+const (
+	// KindReadiness 就绪检查，失败时服务不再接收流量，默认类型
+	KindReadiness Kind = "readiness"
+	// KindLiveness 存活检查，失败时服务需要重启，只注册进程无法自行恢复的检查
+	KindLiveness Kind = "liveness"
+)
+
+// Status 检查状态
+type Status string
+
+const (
+	// StatusOK 所有检查都通过
+	StatusOK Status = "ok"
+	// StatusDegraded 只有非关键检查失败，服务仍然可用
+	StatusDegraded Status = "degraded"
+	// StatusFail 有关键检查失败
+	StatusFail Status = "fail"
+)
+
+// CheckFunc 检查函数，返回 nil 表示检查通过
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	critical bool
+	kinds    []Kind
+	services []string
+}
+
+// Option 检查选项
+type Option func(c *check)
+
+// WithTimeout 设置检查的超时时间，默认3s
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// WithCritical 设置是否为关键检查，默认为关键检查
+// 非关键检查失败时状态为 degraded，接口仍然返回200，gRPC 服务仍然为 SERVING
+func WithCritical(critical bool) Option {
+	return func(c *check) {
+		c.critical = critical
+	}
+}
+
+// WithKinds 设置检查类型，默认为 KindReadiness
+func WithKinds(kinds ...Kind) Option {
+	return func(c *check) {
+		c.kinds = kinds
+	}
+}
+
+// WithServices 设置检查影响的 gRPC 服务，例如 helloworld.Greeter，默认影响所有服务
+func WithServices(services ...string) Option {
+	return func(c *check) {
+		c.services = services
+	}
+}
+
+var (
+	mu     sync.RWMutex
+	checks = map[string]*check{}
+)
+
+// Register 注册检查，相同名字的检查会被覆盖
+func Register(name string, fn CheckFunc, opts ...Option) {
+	c := &check{
+		name:     name,
+		fn:       fn,
+		timeout:  DefaultTimeout,
+		critical: true,
+		kinds:    []Kind{KindReadiness},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	mu.Lock()
+	checks[name] = c
+	mu.Unlock()
+}
+
+// Unregister 删除检查
+func Unregister(name string) {
+	mu.Lock()
+	delete(checks, name)
+	mu.Unlock()
+}
+
+// Result 检查结果
+type Result struct {
+	Status Status            `json:"status"`
+	Checks map[string]string `json:"checks"` // 检查名称对应的结果，通过为 ok，失败为错误信息
+	failed []*check          // 失败的关键检查
+}
+
+// ServiceStatus 返回 gRPC 服务的状态，只受没有指定服务和指定了该服务的关键检查影响
+func (r Result) ServiceStatus(service string) Status {
+	for _, c := range r.failed {
+		if len(c.services) == 0 {
+			return StatusFail
+		}
+		for _, s := range c.services {
+			if s == service {
+				return StatusFail
+			}
+		}
+	}
+	return StatusOK
+}
+
+// Run 并发执行 kind 类型的检查，kind 为空时执行所有检查
+func Run(ctx context.Context, kind Kind) Result {
+	mu.RLock()
+	list := make([]*check, 0, len(checks))
+	for _, c := range checks {
+		if kind == "" || c.hasKind(kind) {
+			list = append(list, c)
+		}
+	}
+	mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+
+	errs := make([]error, len(list))
+	var wg sync.WaitGroup
+	for i, c := range list {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			errs[i] = c.fn(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	result := Result{Status: StatusOK, Checks: make(map[string]string, len(list))}
+	for i, c := range list {
+		if errs[i] == nil {
+			result.Checks[c.name] = "ok"
+			continue
+		}
+		result.Checks[c.name] = errs[i].Error()
+		if c.critical {
+			result.Status = StatusFail
+			result.failed = append(result.failed, c)
+		} else if result.Status == StatusOK {
+			result.Status = StatusDegraded
+		}
+	}
+	return result
+}
+
+// Handler 返回 kind 类型检查的 HTTP 接口，kind 为空时执行所有检查
+// 有关键检查失败时返回503，否则返回200
+func Handler(kind Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := Run(r.Context(), kind)
+		w.Header().Set("Content-Type", "application/json")
+		if result.Status == StatusFail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = jsoniter.NewEncoder(w).Encode(result)
+	}
+}
+
+// Routes 返回健康检查接口的路由，/healthz 执行所有检查，/readyz 执行就绪检查，/livez 执行存活检查
+func Routes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"/healthz": Handler(""),
+		"/readyz":  Handler(KindReadiness),
+		"/livez":   Handler(KindLiveness),
+	}
+}
+
+func (c *check) hasKind(kind Kind) bool {
+	for _, k := range c.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package ehealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	Register("db", func(ctx context.Context) error { return nil })
+	defer Unregister("db")
+	Register("cache", func(ctx context.Context) error { return errors.New("cache down") }, WithCritical(false))
+	defer Unregister("cache")
+	Register("deadlock", func(ctx context.Context) error { return nil }, WithKinds(KindLiveness))
+	defer Unregister("deadlock")
+
+	// 只有非关键检查失败
+	result := Run(context.Background(), KindReadiness)
+	assert.Equal(t, StatusDegraded, result.Status)
+	assert.Equal(t, map[string]string{"db": "ok", "cache": "cache down"}, result.Checks)
+	assert.Equal(t, StatusOK, result.ServiceStatus("helloworld.Greeter"))
+
+	result = Run(context.Background(), KindLiveness)
+	assert.Equal(t, StatusOK, result.Status)
+	assert.Equal(t, map[string]string{"deadlock": "ok"}, result.Checks)
+
+	result = Run(context.Background(), "")
+	assert.Len(t, result.Checks, 3)
+
+	// 关键检查超时
+	Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond), WithServices("helloworld.Greeter"))
+	defer Unregister("slow")
+	result = Run(context.Background(), KindReadiness)
+	assert.Equal(t, StatusFail, result.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), result.Checks["slow"])
+	assert.Equal(t, StatusFail, result.ServiceStatus("helloworld.Greeter"))
+	assert.Equal(t, StatusOK, result.ServiceStatus("helloworld.Other"))
+}
+
+func TestHandler(t *testing.T) {
+	Register("db", func(ctx context.Context) error { return nil })
+	defer Unregister("db")
+
+	w := httptest.NewRecorder()
+	Routes()["/readyz"](w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok","checks":{"db":"ok"}}`, w.Body.String())
+
+	Register("cache", func(ctx context.Context) error { return errors.New("cache down") }, WithCritical(false))
+	defer Unregister("cache")
+	w = httptest.NewRecorder()
+	Routes()["/healthz"](w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"degraded","checks":{"db":"ok","cache":"cache down"}}`, w.Body.String())
+
+	Register("grpc", func(ctx context.Context) error { return errors.New("not ready") })
+	defer Unregister("grpc")
+	w = httptest.NewRecorder()
+	Routes()["/readyz"](w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"fail","checks":{"db":"ok","cache":"cache down","grpc":"not ready"}}`, w.Body.String())
+
+	// 没有存活检查
+	w = httptest.NewRecorder()
+	Routes()["/livez"](w, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok","checks":{}}`, w.Body.String())
+}